@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Identity — игрок, личность которого подтвердил сервер
+type Identity struct {
+	ID   string
+	Name string
+}
+
+// AuthConfig настройки проверки Telegram initData
+type AuthConfig struct {
+	BotToken string        // Токен бота, которым подписан initData
+	MaxAge   time.Duration // Сколько живет initData после auth_date
+	DevMode  bool          // Разрешить тестовые ID "test_..." без подписи
+}
+
+var authConfig = loadAuthConfig()
+
+// loadAuthConfig читает настройки авторизации из окружения
+func loadAuthConfig() AuthConfig {
+	config := AuthConfig{
+		BotToken: os.Getenv("TELEGRAM_BOT_TOKEN"),
+		MaxAge:   24 * time.Hour,
+	}
+
+	if value := os.Getenv("AUTH_MAX_AGE"); value != "" {
+		if maxAge, err := time.ParseDuration(value); err == nil {
+			config.MaxAge = maxAge
+		}
+	}
+
+	switch os.Getenv("DEV_MODE") {
+	case "1", "true", "yes":
+		config.DevMode = true
+	}
+
+	return config
+}
+
+// verifyInitData проверяет подпись initData Telegram WebApp и возвращает игрока
+func verifyInitData(initData, botToken string, maxAge time.Duration, now time.Time) (*Identity, error) {
+	if botToken == "" {
+		return nil, fmt.Errorf("токен бота не настроен")
+	}
+
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, fmt.Errorf("некорректные данные авторизации")
+	}
+
+	hash := values.Get("hash")
+	if hash == "" {
+		return nil, fmt.Errorf("нет подписи в данных авторизации")
+	}
+
+	// Строка для проверки: все поля, кроме hash, по алфавиту через перевод строки
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if key != "hash" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		lines[i] = key + "=" + values.Get(key)
+	}
+
+	secret := hmac.New(sha256.New, []byte("WebAppData"))
+	secret.Write([]byte(botToken))
+	mac := hmac.New(sha256.New, secret.Sum(nil))
+	mac.Write([]byte(strings.Join(lines, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(hash)) {
+		return nil, fmt.Errorf("неверная подпись данных авторизации")
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("некорректная дата авторизации")
+	}
+	if maxAge > 0 && now.Sub(time.Unix(authDate, 0)) > maxAge {
+		return nil, fmt.Errorf("данные авторизации устарели")
+	}
+
+	var user struct {
+		ID        int64  `json:"id"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		Username  string `json:"username"`
+	}
+	if err := json.Unmarshal([]byte(values.Get("user")), &user); err != nil || user.ID == 0 {
+		return nil, fmt.Errorf("нет данных пользователя")
+	}
+
+	name := user.FirstName
+	if name == "" {
+		name = user.Username
+	}
+	if name == "" {
+		name = "Игрок"
+	}
+
+	return &Identity{ID: strconv.FormatInt(user.ID, 10), Name: name}, nil
+}
+
+// authenticate определяет игрока, от имени которого пришел запрос: по токену
+// сессии ("Bearer <token>") или по initData Telegram ("tma <initData>").
+// claimedID и claimedName учитываются только в режиме разработки для ID "test_..."
+func authenticate(r *http.Request, claimedID, claimedName string) (*Identity, error) {
+	header := r.Header.Get("Authorization")
+
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		session, exists := sessionStore.lookup(token)
+		if !exists {
+			return nil, fmt.Errorf("сессия недействительна")
+		}
+		identity := session.Player
+		return &identity, nil
+	}
+
+	if initData, ok := strings.CutPrefix(header, "tma "); ok {
+		return verifyInitData(initData, authConfig.BotToken, authConfig.MaxAge, time.Now())
+	}
+
+	if authConfig.DevMode && strings.HasPrefix(claimedID, "test_") {
+		if claimedName == "" {
+			claimedName = "Тестовый игрок"
+		}
+		return &Identity{ID: claimedID, Name: claimedName}, nil
+	}
+
+	return nil, fmt.Errorf("требуется авторизация Telegram")
+}
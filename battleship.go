@@ -0,0 +1,589 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+)
+
+// BattleshipState состояние игры в морской бой
+type BattleshipState struct {
+	Rules     BattleshipRules `json:"rules"`
+	Boards    []Board         `json:"boards"`
+	LastShots []ShotResult    `json:"lastShots"` // Итоги последнего хода
+}
+
+// Board для морского боя (Rules.Size x Rules.Size)
+type Board struct {
+	Grid  [][]string `json:"grid"`  // Сетка игрока, Grid[y][x]
+	Ships []Ship     `json:"ships"` // Корабли игрока
+	Ready bool       `json:"ready"` // Готов ли игрок
+}
+
+// Ship представляет корабль в морском бое
+type Ship struct {
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Length    int    `json:"length"`
+	Direction string `json:"direction"` // "horizontal" или "vertical"
+	Hits      int    `json:"hits"`
+}
+
+// ShotResult итог одного выстрела
+type ShotResult struct {
+	Player int      `json:"player"` // Индекс стрелявшего игрока
+	X      int      `json:"x"`
+	Y      int      `json:"y"`
+	Result string   `json:"result"`          // "miss", "hit", "sunk" или "win"
+	Ship   *Ship    `json:"ship,omitempty"`  // Потопленный корабль
+	Cells  [][2]int `json:"cells,omitempty"` // Клетки потопленного корабля
+}
+
+// AttackData для атаки в морском бое
+type AttackData struct {
+	GameID   string `json:"gameId"`
+	X        int    `json:"x"`
+	Y        int    `json:"y"`
+	PlayerID string `json:"playerId"`
+}
+
+// SalvoData залп в режиме залпов: все выстрелы хода сразу
+type SalvoData struct {
+	GameID   string   `json:"gameId"`
+	Shots    [][2]int `json:"shots"` // Клетки (x, y)
+	PlayerID string   `json:"playerId"`
+}
+
+// ShipPlacementData для размещения кораблей
+type ShipPlacementData struct {
+	GameID   string `json:"gameId"`
+	PlayerID string `json:"playerId"`
+	Ships    []Ship `json:"ships"`
+	Random   bool   `json:"random,omitempty"` // Расставить флот случайно на сервере
+	Seed     *int64 `json:"seed,omitempty"`   // Зерно случайной расстановки
+}
+
+// RandomFleet случайная расстановка и зерно, из которого она получена
+type RandomFleet struct {
+	Seed  int64  `json:"seed"`
+	Ships []Ship `json:"ships"`
+}
+
+// BattleshipEngine правила морского боя
+type BattleshipEngine struct{}
+
+func init() {
+	registerEngine("battleship", BattleshipEngine{})
+}
+
+func (BattleshipEngine) NewState(options json.RawMessage) (interface{}, error) {
+	rules, err := parseBattleshipRules(options)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &BattleshipState{Rules: rules, Boards: make([]Board, 2), LastShots: []ShotResult{}}
+	for i := range state.Boards {
+		state.Boards[i] = Board{
+			Grid:  newGrid(rules.Size),
+			Ships: []Ship{},
+			Ready: false,
+		}
+	}
+	return state, nil
+}
+
+// ValidateOptions проверяет, что флот из настроек помещается на поле
+func (BattleshipEngine) ValidateOptions(options json.RawMessage) error {
+	rules, err := parseBattleshipRules(options)
+	if err != nil {
+		return err
+	}
+	_, err = rules.packFleet()
+	return err
+}
+
+func (BattleshipEngine) Symbol(playerIndex int) string {
+	return ""
+}
+
+func (BattleshipEngine) StartStatus() string {
+	return "setup" // Фаза расстановки кораблей
+}
+
+func (e BattleshipEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	switch action {
+	case "placeShips":
+		var placement ShipPlacementData
+		if err := json.Unmarshal(data, &placement); err != nil {
+			return fmt.Errorf("некорректная расстановка кораблей")
+		}
+		if placement.Random {
+			// Зерно подставляет NormalizeAction: без него расстановку нельзя повторить
+			if placement.Seed == nil {
+				return fmt.Errorf("для случайной расстановки не указано зерно")
+			}
+			rules := game.State.(*BattleshipState).Rules
+			ships, err := randomFleet(rand.New(rand.NewSource(*placement.Seed)), rules)
+			if err != nil {
+				return err
+			}
+			placement.Ships = ships
+		}
+		return e.placeShips(game, playerIndex, placement.Ships)
+
+	case "attack":
+		var attack AttackData
+		if err := json.Unmarshal(data, &attack); err != nil {
+			return fmt.Errorf("некорректная атака")
+		}
+		return e.attack(game, playerIndex, attack.X, attack.Y)
+
+	case "salvo":
+		var salvo SalvoData
+		if err := json.Unmarshal(data, &salvo); err != nil {
+			return fmt.Errorf("некорректный залп")
+		}
+		return e.salvo(game, playerIndex, salvo.Shots)
+	}
+
+	return fmt.Errorf("неизвестное действие")
+}
+
+// NormalizeAction подставляет зерно в случайную расстановку, если клиент его не указал
+func (BattleshipEngine) NormalizeAction(game *Game, playerIndex int, action string, data json.RawMessage) (json.RawMessage, error) {
+	if action != "placeShips" {
+		return data, nil
+	}
+
+	var placement ShipPlacementData
+	if err := json.Unmarshal(data, &placement); err != nil {
+		return nil, fmt.Errorf("некорректная расстановка кораблей")
+	}
+	if !placement.Random || placement.Seed != nil {
+		return data, nil
+	}
+
+	seed := rand.Int63()
+	placement.Seed = &seed
+	return json.Marshal(placement)
+}
+
+// placeShips размещает корабли для морского боя
+func (BattleshipEngine) placeShips(game *Game, playerIndex int, ships []Ship) error {
+	state := game.State.(*BattleshipState)
+
+	if game.Status != "setup" {
+		return fmt.Errorf("фаза расстановки завершена")
+	}
+
+	// Проверяем корректность расстановки кораблей
+	if violations := validateShipPlacement(ships, state.Rules); len(violations) > 0 {
+		return &PlacementError{Violations: violations}
+	}
+
+	board := &state.Boards[playerIndex]
+
+	// Размещаем корабли. Попадания считает сервер: присланные клиентом не принимаются
+	board.Ships = make([]Ship, len(ships))
+	for i, ship := range ships {
+		ship.Hits = 0
+		board.Ships[i] = ship
+	}
+	board.Ready = true
+
+	// Обновляем сетку
+	board.Grid = newGrid(state.Rules.Size)
+	for _, ship := range ships {
+		for _, cell := range shipCells(ship) {
+			board.Grid[cell[1]][cell[0]] = "ship"
+		}
+	}
+
+	// Если оба игрока готовы, начинаем игру
+	if len(game.Players) == 2 && state.Boards[0].Ready && state.Boards[1].Ready {
+		game.Status = "playing"
+		game.logf("Игра морской бой %s началась", game.ID)
+	}
+
+	return nil
+}
+
+// attack делает атаку в морском бое
+func (BattleshipEngine) attack(game *Game, playerIndex, x, y int) error {
+	state := game.State.(*BattleshipState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	if state.Rules.Salvo {
+		return fmt.Errorf("в режиме залпов стреляйте залпом")
+	}
+
+	// Доска противника
+	target := &state.Boards[1-playerIndex]
+
+	if err := checkShot(target, state.Rules, x, y); err != nil {
+		return err
+	}
+
+	result := fire(game, target, state.Rules, x, y)
+	result.Player = playerIndex
+	state.LastShots = []ShotResult{result}
+
+	if result.Result == "miss" {
+		// Промах — ход переходит
+		game.Turn = 1 - game.Turn
+	}
+
+	return nil
+}
+
+// salvo делает залп: столько выстрелов, сколько у игрока осталось кораблей.
+// Залп принимается целиком или отклоняется, после него ход всегда переходит
+func (BattleshipEngine) salvo(game *Game, playerIndex int, shots [][2]int) error {
+	state := game.State.(*BattleshipState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	if !state.Rules.Salvo {
+		return fmt.Errorf("залпы доступны только в режиме залпов")
+	}
+
+	target := &state.Boards[1-playerIndex]
+
+	// Выстрелов не больше, чем осталось неатакованных клеток
+	allowed := min(survivingShips(state.Boards[playerIndex].Ships), unattackedCells(target))
+	if len(shots) != allowed {
+		return fmt.Errorf("в залпе должно быть выстрелов: %d", allowed)
+	}
+
+	seen := make(map[[2]int]bool, len(shots))
+	for _, shot := range shots {
+		if seen[shot] {
+			return fmt.Errorf("клетка (%d, %d) указана в залпе дважды", shot[0], shot[1])
+		}
+		seen[shot] = true
+		if err := checkShot(target, state.Rules, shot[0], shot[1]); err != nil {
+			return err
+		}
+	}
+
+	state.LastShots = make([]ShotResult, 0, len(shots))
+	for _, shot := range shots {
+		result := fire(game, target, state.Rules, shot[0], shot[1])
+		result.Player = playerIndex
+		state.LastShots = append(state.LastShots, result)
+	}
+
+	game.Turn = 1 - game.Turn
+	return nil
+}
+
+// checkShot проверяет, что по клетке (x, y) можно выстрелить
+func checkShot(target *Board, rules BattleshipRules, x, y int) error {
+	if !rules.inBounds(x, y) {
+		return fmt.Errorf("неверные координаты")
+	}
+
+	// Проверяем, не атаковали ли уже эту клетку
+	switch target.Grid[y][x] {
+	case "hit", "miss", "sunk":
+		return fmt.Errorf("клетка уже атакована")
+	}
+	return nil
+}
+
+// fire отмечает выстрел по клетке (x, y). Потопленный корабль помечается
+// целиком, а клетки вокруг него, где по правилам не может быть кораблей, — промахами
+func fire(game *Game, target *Board, rules BattleshipRules, x, y int) ShotResult {
+	result := ShotResult{X: x, Y: y, Result: "miss"}
+
+	if target.Grid[y][x] != "ship" {
+		target.Grid[y][x] = "miss"
+		return result
+	}
+
+	target.Grid[y][x] = "hit"
+	result.Result = "hit"
+
+	// Проверяем, потоплен ли корабль
+	for i, ship := range target.Ships {
+		if !isShipHit(&ship, x, y) {
+			continue
+		}
+
+		target.Ships[i].Hits++
+		if target.Ships[i].Hits < ship.Length {
+			break
+		}
+
+		sunk := target.Ships[i]
+		result.Result = "sunk"
+		result.Ship = &sunk
+		result.Cells = shipCells(sunk)
+		game.logf("Корабль потоплен в игре %s", game.ID)
+
+		for _, cell := range result.Cells {
+			target.Grid[cell[1]][cell[0]] = "sunk"
+		}
+		for _, cell := range result.Cells {
+			forNeighbours(cell[0], cell[1], rules.Size, func(nx, ny int) {
+				if target.Grid[ny][nx] == "" && rules.conflicts(cell, [2]int{nx, ny}) {
+					target.Grid[ny][nx] = "miss"
+				}
+			})
+		}
+
+		if allShipsSunk(target.Ships) {
+			result.Result = "win"
+		}
+		break
+	}
+
+	return result
+}
+
+func (BattleshipEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*BattleshipState)
+	for i, board := range state.Boards {
+		if board.Ready && allShipsSunk(board.Ships) {
+			// Побеждает владелец другой доски
+			if i == 0 {
+				return true, "player2"
+			}
+			return true, "player1"
+		}
+	}
+	return false, ""
+}
+
+// View оставляет на чужих досках только результаты выстрелов и потопленные корабли
+func (BattleshipEngine) View(game *Game, playerIndex int) interface{} {
+	state := game.State.(*BattleshipState)
+
+	boards := make([]Board, len(state.Boards))
+	for i, board := range state.Boards {
+		if i == playerIndex {
+			boards[i] = board
+			boards[i].Grid = copyGrid(board.Grid)
+			boards[i].Ships = append([]Ship{}, board.Ships...)
+			continue
+		}
+
+		boards[i] = Board{Grid: newGrid(state.Rules.Size), Ships: []Ship{}, Ready: board.Ready}
+		for y := range board.Grid {
+			for x, cell := range board.Grid[y] {
+				switch cell {
+				case "hit", "miss", "sunk":
+					boards[i].Grid[y][x] = cell
+				}
+			}
+		}
+		for _, ship := range board.Ships {
+			if ship.Hits >= ship.Length {
+				boards[i].Ships = append(boards[i].Ships, ship)
+			}
+		}
+	}
+	return &BattleshipState{Rules: state.Rules, Boards: boards, LastShots: append([]ShotResult{}, state.LastShots...)}
+}
+
+func (e BattleshipEngine) Reset(game *Game) {
+	// Настройки проверены при создании игры
+	game.State, _ = e.NewState(game.Options)
+}
+
+// Вспомогательные функции
+
+// randomFleet расставляет флот случайным образом по правилам validateShipPlacement.
+// Плотный флот, который не удается расставить случайно за несколько попыток,
+// расставляется детерминированно через packFleet
+func randomFleet(rng *rand.Rand, rules BattleshipRules) ([]Ship, error) {
+	// Сначала длинные корабли — для них меньше свободного места
+	lengths := rules.lengths()
+
+	for restart := 0; restart < 10; restart++ {
+		ships := make([]Ship, 0, len(lengths))
+		for _, length := range lengths {
+			placed := false
+			for attempt := 0; attempt < 200 && !placed; attempt++ {
+				ship := Ship{
+					X:         rng.Intn(rules.Size),
+					Y:         rng.Intn(rules.Size),
+					Length:    length,
+					Direction: "horizontal",
+				}
+				if rng.Intn(2) == 1 {
+					ship.Direction = "vertical"
+				}
+				if canPlaceShip(ships, ship, rules) {
+					ships = append(ships, ship)
+					placed = true
+				}
+			}
+			if !placed {
+				break // Тупик — начинаем расстановку заново
+			}
+		}
+		if len(ships) == len(lengths) {
+			return ships, nil
+		}
+	}
+	return rules.packFleet()
+}
+
+// randomFleetHandler возвращает случайную расстановку для предпросмотра.
+// Параметр seed позволяет получить ту же расстановку повторно, gameId — взять
+// правила игры, preset — готовый набор правил (по умолчанию классический)
+func randomFleetHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var rules BattleshipRules
+	if gameID := query.Get("gameId"); gameID != "" {
+		view, exists := gameManager.view(gameID, "")
+		if !exists {
+			http.Error(w, "Игра не найдена", http.StatusNotFound)
+			return
+		}
+		state, ok := view.State.(*BattleshipState)
+		if !ok {
+			http.Error(w, "Это не морской бой", http.StatusBadRequest)
+			return
+		}
+		rules = state.Rules
+	} else {
+		options, _ := json.Marshal(map[string]string{"preset": query.Get("preset")})
+		parsed, err := parseBattleshipRules(options)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rules = parsed
+	}
+
+	seed := rand.Int63()
+	if value := query.Get("seed"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Неверное зерно", http.StatusBadRequest)
+			return
+		}
+		seed = parsed
+	}
+
+	ships, err := randomFleet(rand.New(rand.NewSource(seed)), rules)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fleet := RandomFleet{Seed: seed, Ships: ships}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fleet)
+}
+
+// canPlaceShip проверяет, что корабль помещается на поле и не стоит к уже
+// расставленным ближе, чем позволяют правила
+func canPlaceShip(ships []Ship, ship Ship, rules BattleshipRules) bool {
+	for _, cell := range shipCells(ship) {
+		if !rules.inBounds(cell[0], cell[1]) {
+			return false
+		}
+		for _, other := range ships {
+			for _, otherCell := range shipCells(other) {
+				if rules.conflicts(cell, otherCell) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// shipCells возвращает координаты (x, y) клеток корабля
+func shipCells(ship Ship) [][2]int {
+	cells := make([][2]int, ship.Length)
+	for i := range cells {
+		x, y := ship.X, ship.Y
+		if ship.Direction == "horizontal" {
+			x += i
+		} else {
+			y += i
+		}
+		cells[i] = [2]int{x, y}
+	}
+	return cells
+}
+
+// forNeighbours вызывает fn для всех клеток поля size x size вокруг (x, y)
+func forNeighbours(x, y, size int, fn func(nx, ny int)) {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := x+dx, y+dy
+			if (dx != 0 || dy != 0) && nx >= 0 && nx < size && ny >= 0 && ny < size {
+				fn(nx, ny)
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func isShipHit(ship *Ship, x, y int) bool {
+	for i := 0; i < ship.Length; i++ {
+		sx, sy := ship.X, ship.Y
+		if ship.Direction == "horizontal" {
+			sx += i
+		} else {
+			sy += i
+		}
+		if sx == x && sy == y {
+			return true
+		}
+	}
+	return false
+}
+
+// survivingShips возвращает число непотопленных кораблей
+func survivingShips(ships []Ship) int {
+	count := 0
+	for _, ship := range ships {
+		if ship.Hits < ship.Length {
+			count++
+		}
+	}
+	return count
+}
+
+// unattackedCells возвращает число клеток доски, по которым еще не стреляли
+func unattackedCells(board *Board) int {
+	count := 0
+	for _, row := range board.Grid {
+		for _, cell := range row {
+			if cell == "" || cell == "ship" {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func allShipsSunk(ships []Ship) bool {
+	for _, ship := range ships {
+		if ship.Hits < ship.Length {
+			return false
+		}
+	}
+	return true
+}
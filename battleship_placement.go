@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PlacementViolation — одно нарушение правил расстановки кораблей.
+// Корабли указываются индексами в присланном списке, клетки — парами (x, y)
+type PlacementViolation struct {
+	Code     string   `json:"code"` // "invalid_direction", "invalid_length", "out_of_bounds", "overlap", "touching", "fleet_count"
+	Ships    []int    `json:"ships,omitempty"`
+	Cells    [][2]int `json:"cells,omitempty"`
+	Length   int      `json:"length,omitempty"`   // Для "fleet_count": длина кораблей
+	Expected int      `json:"expected,omitempty"` // Для "fleet_count": сколько нужно
+	Actual   int      `json:"actual,omitempty"`   // Для "fleet_count": сколько прислано
+	Message  string   `json:"message"`
+}
+
+// PlacementError — расстановка отклонена, в Violations перечислены все нарушения
+type PlacementError struct {
+	Violations []PlacementViolation
+}
+
+func (e *PlacementError) Error() string {
+	return "некорректная расстановка кораблей"
+}
+
+// Details отдает нарушения клиенту, чтобы интерфейс мог подсветить корабли
+func (e *PlacementError) Details() map[string]interface{} {
+	return map[string]interface{}{
+		"code":       "invalid_placement",
+		"violations": e.Violations,
+	}
+}
+
+// validateShipPlacement проверяет расстановку и возвращает все найденные нарушения
+func validateShipPlacement(ships []Ship, rules BattleshipRules) []PlacementViolation {
+	var violations []PlacementViolation
+	fleetCounts := rules.counts()
+	shipCounts := map[int]int{}
+
+	// Корабли, которые можно разместить на поле: для остальных пересечения не проверяем
+	var placed []int
+
+	for i, ship := range ships {
+		shipCounts[ship.Length]++
+
+		if ship.Direction != "horizontal" && ship.Direction != "vertical" {
+			violations = append(violations, PlacementViolation{
+				Code:    "invalid_direction",
+				Ships:   []int{i},
+				Message: fmt.Sprintf("Корабль %d: неизвестное направление %q", i+1, ship.Direction),
+			})
+			continue
+		}
+
+		if _, allowed := fleetCounts[ship.Length]; !allowed {
+			violations = append(violations, PlacementViolation{
+				Code:    "invalid_length",
+				Ships:   []int{i},
+				Length:  ship.Length,
+				Message: fmt.Sprintf("Корабль %d: недопустимая длина %d", i+1, ship.Length),
+			})
+			continue
+		}
+
+		var outside [][2]int
+		for _, cell := range shipCells(ship) {
+			if !rules.inBounds(cell[0], cell[1]) {
+				outside = append(outside, cell)
+			}
+		}
+		if len(outside) > 0 {
+			violations = append(violations, PlacementViolation{
+				Code:    "out_of_bounds",
+				Ships:   []int{i},
+				Cells:   outside,
+				Message: fmt.Sprintf("Корабль %d выходит за границы поля", i+1),
+			})
+			continue
+		}
+
+		placed = append(placed, i)
+	}
+
+	// Пересечения и касания проверяем попарно
+	for a := 0; a < len(placed); a++ {
+		for b := a + 1; b < len(placed); b++ {
+			i, j := placed[a], placed[b]
+			overlap, touching := compareShips(ships[i], ships[j], rules)
+
+			switch {
+			case len(overlap) > 0:
+				violations = append(violations, PlacementViolation{
+					Code:    "overlap",
+					Ships:   []int{i, j},
+					Cells:   overlap,
+					Message: fmt.Sprintf("Корабли %d и %d пересекаются", i+1, j+1),
+				})
+			case len(touching) > 0:
+				violations = append(violations, PlacementViolation{
+					Code:    "touching",
+					Ships:   []int{i, j},
+					Cells:   touching,
+					Message: fmt.Sprintf("Корабли %d и %d касаются", i+1, j+1),
+				})
+			}
+		}
+	}
+
+	// Проверяем количество кораблей каждого типа
+	lengths := make([]int, 0, len(fleetCounts))
+	for length := range fleetCounts {
+		lengths = append(lengths, length)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+
+	for _, length := range lengths {
+		if required := fleetCounts[length]; shipCounts[length] != required {
+			violations = append(violations, PlacementViolation{
+				Code:     "fleet_count",
+				Length:   length,
+				Expected: required,
+				Actual:   shipCounts[length],
+				Message:  fmt.Sprintf("Кораблей длины %d: %d, нужно %d", length, shipCounts[length], required),
+			})
+		}
+	}
+
+	return violations
+}
+
+// compareShips возвращает общие клетки двух кораблей и клетки, которыми
+// они касаются вопреки правилам
+func compareShips(a, b Ship, rules BattleshipRules) (overlap, touching [][2]int) {
+	seen := map[[2]int]bool{}
+	for _, cellA := range shipCells(a) {
+		for _, cellB := range shipCells(b) {
+			switch {
+			case cellA == cellB:
+				overlap = append(overlap, cellA)
+			case rules.conflicts(cellA, cellB):
+				for _, cell := range [][2]int{cellA, cellB} {
+					if !seen[cell] {
+						seen[cell] = true
+						touching = append(touching, cell)
+					}
+				}
+			}
+		}
+	}
+	return overlap, touching
+}
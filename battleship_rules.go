@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// BattleshipRules правила партии в морской бой
+type BattleshipRules struct {
+	Preset   string       `json:"preset,omitempty"` // Набор, из которого взяты правила
+	Size     int          `json:"size"`             // Сторона квадратного поля, от 6 до 15
+	Fleet    []FleetEntry `json:"fleet"`            // Состав флота
+	Touching string       `json:"touching"`         // "none", "diagonal" (только углами) или "any"
+	Salvo    bool         `json:"salvo"`            // Режим залпов: столько выстрелов за ход, сколько осталось кораблей
+}
+
+// FleetEntry сколько кораблей одной длины во флоте
+type FleetEntry struct {
+	Length int `json:"length"`
+	Count  int `json:"count"`
+}
+
+const (
+	minBoardSize = 6
+	maxBoardSize = 15
+)
+
+// battleshipPresets готовые наборы правил
+var battleshipPresets = map[string]BattleshipRules{
+	// Классические правила: 1x4, 2x3, 3x2, 4x1, корабли не касаются
+	"classic": {
+		Size:     10,
+		Fleet:    []FleetEntry{{4, 1}, {3, 2}, {2, 3}, {1, 4}},
+		Touching: "none",
+	},
+	// Правила Hasbro: авианосец, линкор, крейсер, подлодка и эсминец вплотную
+	"hasbro": {
+		Size:     10,
+		Fleet:    []FleetEntry{{5, 1}, {4, 1}, {3, 2}, {2, 1}},
+		Touching: "any",
+	},
+	// Короткая партия на поле 6x6
+	"mini": {
+		Size:     6,
+		Fleet:    []FleetEntry{{3, 1}, {2, 2}, {1, 3}},
+		Touching: "none",
+	},
+}
+
+// parseBattleshipRules разбирает настройки партии. Поля, указанные явно,
+// заменяют значения из набора preset (по умолчанию — классические правила)
+func parseBattleshipRules(options json.RawMessage) (BattleshipRules, error) {
+	var selected struct {
+		Preset string `json:"preset"`
+	}
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &selected); err != nil {
+			return BattleshipRules{}, fmt.Errorf("некорректные настройки игры")
+		}
+	}
+	if selected.Preset == "" {
+		selected.Preset = "classic"
+	}
+
+	rules, exists := battleshipPresets[selected.Preset]
+	if !exists {
+		return BattleshipRules{}, fmt.Errorf("неизвестный набор правил %q", selected.Preset)
+	}
+	rules.Preset = selected.Preset
+	rules.Fleet = append([]FleetEntry{}, rules.Fleet...)
+
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &rules); err != nil {
+			return BattleshipRules{}, fmt.Errorf("некорректные настройки игры")
+		}
+	}
+
+	if err := rules.validate(); err != nil {
+		return BattleshipRules{}, err
+	}
+	return rules, nil
+}
+
+// validate проверяет, что по правилам можно играть
+func (r BattleshipRules) validate() error {
+	if r.Size < minBoardSize || r.Size > maxBoardSize {
+		return fmt.Errorf("размер поля должен быть от %d до %d", minBoardSize, maxBoardSize)
+	}
+
+	switch r.Touching {
+	case "none", "diagonal", "any":
+	default:
+		return fmt.Errorf("неизвестное правило касания %q", r.Touching)
+	}
+
+	if len(r.Fleet) == 0 {
+		return fmt.Errorf("флот не может быть пустым")
+	}
+	seen := map[int]bool{}
+	cells := 0
+	for _, entry := range r.Fleet {
+		if entry.Length < 1 || entry.Length > r.Size {
+			return fmt.Errorf("недопустимая длина корабля %d", entry.Length)
+		}
+		// Кораблей не может быть больше, чем клеток на поле
+		if entry.Count < 1 || entry.Count > r.Size*r.Size {
+			return fmt.Errorf("недопустимое количество кораблей длины %d", entry.Length)
+		}
+		if seen[entry.Length] {
+			return fmt.Errorf("длина корабля %d указана дважды", entry.Length)
+		}
+		seen[entry.Length] = true
+		cells += entry.Length * entry.Count
+	}
+	if cells > r.Size*r.Size {
+		return fmt.Errorf("флот занимает %d клеток и не помещается на поле %dx%d", cells, r.Size, r.Size)
+	}
+	return nil
+}
+
+// packFleet расставляет флот детерминированно: корабли от длинных к коротким
+// встают на первое подходящее место при обходе поля по строкам. Если так
+// расставить флот не удалось, он считается не помещающимся на поле
+func (r BattleshipRules) packFleet() ([]Ship, error) {
+	// Клетки, на которые нельзя ставить новые корабли: сами корабли и,
+	// в зависимости от правила касания, соседние с ними клетки
+	blocked := make([][]bool, r.Size)
+	for y := range blocked {
+		blocked[y] = make([]bool, r.Size)
+	}
+
+	var ships []Ship
+	for _, length := range r.lengths() {
+		ship, found := r.firstFreeSpot(blocked, length)
+		if !found {
+			return nil, fmt.Errorf("флот не помещается на поле")
+		}
+		ships = append(ships, ship)
+
+		for _, cell := range shipCells(ship) {
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					x, y := cell[0]+dx, cell[1]+dy
+					if r.inBounds(x, y) && r.conflicts(cell, [2]int{x, y}) {
+						blocked[y][x] = true
+					}
+				}
+			}
+		}
+	}
+	return ships, nil
+}
+
+// firstFreeSpot ищет первое место для корабля длины length, не задевающее занятые клетки
+func (r BattleshipRules) firstFreeSpot(blocked [][]bool, length int) (Ship, bool) {
+	for y := 0; y < r.Size; y++ {
+		for x := 0; x < r.Size; x++ {
+			for _, direction := range []string{"horizontal", "vertical"} {
+				ship := Ship{X: x, Y: y, Length: length, Direction: direction}
+				free := true
+				for _, cell := range shipCells(ship) {
+					if !r.inBounds(cell[0], cell[1]) || blocked[cell[1]][cell[0]] {
+						free = false
+						break
+					}
+				}
+				if free {
+					return ship, true
+				}
+			}
+		}
+	}
+	return Ship{}, false
+}
+
+// counts возвращает, сколько кораблей каждой длины во флоте
+func (r BattleshipRules) counts() map[int]int {
+	counts := make(map[int]int, len(r.Fleet))
+	for _, entry := range r.Fleet {
+		counts[entry.Length] += entry.Count
+	}
+	return counts
+}
+
+// lengths возвращает длины всех кораблей флота, от длинных к коротким
+func (r BattleshipRules) lengths() []int {
+	var lengths []int
+	for _, entry := range r.Fleet {
+		for i := 0; i < entry.Count; i++ {
+			lengths = append(lengths, entry.Length)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	return lengths
+}
+
+// inBounds проверяет, что клетка (x, y) на поле
+func (r BattleshipRules) inBounds(x, y int) bool {
+	return x >= 0 && x < r.Size && y >= 0 && y < r.Size
+}
+
+// conflicts сообщает, что клетки a и b разных кораблей стоят ближе, чем позволяют правила
+func (r BattleshipRules) conflicts(a, b [2]int) bool {
+	dx, dy := abs(a[0]-b[0]), abs(a[1]-b[1])
+	switch {
+	case dx == 0 && dy == 0:
+		return true
+	case dx+dy == 1:
+		return r.Touching != "any"
+	case dx == 1 && dy == 1:
+		return r.Touching == "none"
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestBattleshipPackFleet(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules BattleshipRules
+		fits  bool
+	}{
+		{"классический флот", battleshipPresets["classic"], true},
+		{"флот Hasbro", battleshipPresets["hasbro"], true},
+		{"мини", battleshipPresets["mini"], true},
+		// Помещается только в шахматном порядке через строку
+		{"64 одноклеточных без касаний", BattleshipRules{Size: 15, Fleet: []FleetEntry{{1, 64}}, Touching: "none"}, true},
+		{"65 одноклеточных без касаний", BattleshipRules{Size: 15, Fleet: []FleetEntry{{1, 65}}, Touching: "none"}, false},
+		{"все поле занято", BattleshipRules{Size: 6, Fleet: []FleetEntry{{6, 6}}, Touching: "any"}, true},
+		{"касания углами", BattleshipRules{Size: 6, Fleet: []FleetEntry{{1, 18}}, Touching: "diagonal"}, true},
+		{"слишком много касаний углами", BattleshipRules{Size: 6, Fleet: []FleetEntry{{1, 19}}, Touching: "diagonal"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ships, err := tt.rules.packFleet()
+			if (err == nil) != tt.fits {
+				t.Fatalf("packFleet() ошибка = %v, ожидалось помещается = %v", err, tt.fits)
+			}
+			if err != nil {
+				return
+			}
+			if violations := validateShipPlacement(ships, tt.rules); len(violations) > 0 {
+				t.Errorf("packFleet() нарушает правила: %v", violations)
+			}
+		})
+	}
+}
+
+func TestBattleshipRandomFleetFallsBackToPacking(t *testing.T) {
+	// Случайная расстановка такого флота почти никогда не удается
+	rules := BattleshipRules{Size: 15, Fleet: []FleetEntry{{1, 64}}, Touching: "none"}
+	ships, err := randomFleet(rand.New(rand.NewSource(1)), rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if violations := validateShipPlacement(ships, rules); len(violations) > 0 {
+		t.Errorf("randomFleet() нарушает правила: %v", violations)
+	}
+}
+
+func TestBattleshipValidateOptions(t *testing.T) {
+	engine := BattleshipEngine{}
+	tests := []struct {
+		options string
+		valid   bool
+	}{
+		{`{"preset":"classic"}`, true},
+		{`{"size":15,"fleet":[{"length":1,"count":64}],"touching":"none"}`, true},
+		{`{"size":6,"fleet":[{"length":3,"count":6}],"touching":"none"}`, false},
+		{`{"size":6,"fleet":[{"length":1,"count":20000000}]}`, false},
+	}
+
+	for _, tt := range tests {
+		_, err := validateOptions(engine, json.RawMessage(tt.options))
+		if (err == nil) != tt.valid {
+			t.Errorf("validateOptions(%s) ошибка = %v, ожидалось допустимо = %v", tt.options, err, tt.valid)
+		}
+	}
+
+	// Состояние из сохраненных настроек создается без проверки расстановки
+	if _, err := engine.NewState(json.RawMessage(`{"size":6,"fleet":[{"length":3,"count":6}],"touching":"none"}`)); err != nil {
+		t.Errorf("NewState() проверяет расстановку: %v", err)
+	}
+}
+
+func TestBattleshipRandomPlacementWithoutSeed(t *testing.T) {
+	engine := BattleshipEngine{}
+	state, _ := engine.NewState(nil)
+	game := &Game{State: state, Status: "setup", Players: []Player{{ID: "a"}, {ID: "b"}}}
+
+	if err := engine.Apply(game, 0, "placeShips", json.RawMessage(`{"random":true}`)); err == nil {
+		t.Error("Apply() принял случайную расстановку без зерна")
+	}
+	if err := engine.Apply(game, 0, "placeShips", json.RawMessage(`{"random":true,"seed":7}`)); err != nil {
+		t.Errorf("Apply() с зерном: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Bot выбирает действия за игрока-бота. Бот видит игру так же, как человек:
+// ему передается представление игры для его места
+type Bot interface {
+	// NextAction возвращает следующее действие или ok=false, если сейчас ходить не нужно
+	NextAction(view *Game, playerIndex int) (action string, data interface{}, ok bool)
+	// Delay — пауза перед действием, чтобы ход бота был заметен в интерфейсе
+	Delay() time.Duration
+}
+
+// botRetries сколько раз бот пробует сходить снова после отклоненного
+// действия. Затем бот сдается, чтобы игра не зависла на его ходе
+const botRetries = 3
+
+// botFactories создают ботов по типу игры и уровню сложности
+var botFactories = map[string]func(difficulty string) (Bot, error){}
+
+// botNames отображаемые имена ботов по уровню сложности
+var botNames = map[string]string{
+	"easy":       "🤖 Бот (легкий)",
+	"medium":     "🤖 Бот (средний)",
+	"impossible": "🤖 Бот (непобедимый)",
+}
+
+// registerBot регистрирует бота для типа игры. Вызывается из init() файла бота
+func registerBot(gameType string, factory func(difficulty string) (Bot, error)) {
+	botFactories[gameType] = factory
+}
+
+// newBot создает бота для игры
+func newBot(gameType, difficulty string) (Bot, error) {
+	factory, exists := botFactories[gameType]
+	if !exists {
+		return nil, fmt.Errorf("для этой игры нет бота")
+	}
+	return factory(difficulty)
+}
+
+// botRandomness доля случайных ходов для уровня сложности
+func botRandomness(difficulty string) (float64, error) {
+	switch difficulty {
+	case "easy":
+		return 0.6, nil
+	case "medium":
+		return 0.25, nil
+	case "impossible":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("неизвестный уровень сложности")
+}
+
+// seatBot сажает бота вторым игроком и начинает игру
+func (gm *GameManager) seatBot(gameID, difficulty string) (*Game, error) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		return nil, fmt.Errorf("игра не найдена")
+	}
+
+	if game.Status != "waiting" || len(game.Players) != 1 {
+		return nil, fmt.Errorf("игра уже началась")
+	}
+
+	if _, err := newBot(game.Type, difficulty); err != nil {
+		return nil, err
+	}
+
+	engine, _ := engineFor(game.Type)
+	game.Players = append(game.Players, Player{
+		ID:     "bot_" + generateGameID(),
+		Name:   botNames[difficulty],
+		Symbol: engine.Symbol(1),
+		Bot:    difficulty,
+	})
+	game.Status = engine.StartStatus()
+	game.Started = time.Now()
+	log.Printf("Игра %s (%s) началась: %s vs бот (%s)", gameID, game.Type, game.Players[0].Name, difficulty)
+
+	gm.persist(game)
+	gm.scheduleBots(game)
+	return game, nil
+}
+
+// scheduleBots запускает ход ботов, если в игре они есть. Вызывается под блокировкой
+func (gm *GameManager) scheduleBots(game *Game) {
+	if gm.botPending[game.ID] || game.Status == "finished" || game.Status == "restart_requested" {
+		return
+	}
+
+	for _, player := range game.Players {
+		if player.Bot == "" {
+			continue
+		}
+		bot, err := newBot(game.Type, player.Bot)
+		if err != nil {
+			continue
+		}
+
+		gm.botPending[game.ID] = true
+		gameID := game.ID
+		time.AfterFunc(bot.Delay(), func() {
+			gm.runBots(gameID)
+		})
+		return
+	}
+}
+
+// runBots делает одно действие за бота, которому сейчас нужно ходить
+func (gm *GameManager) runBots(gameID string) {
+	gm.mutex.Lock()
+	delete(gm.botPending, gameID)
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		gm.mutex.Unlock()
+		return
+	}
+
+	acted := false
+	for i, player := range game.Players {
+		if player.Bot == "" {
+			continue
+		}
+		bot, err := newBot(game.Type, player.Bot)
+		if err != nil {
+			continue
+		}
+
+		action, data, ok := bot.NextAction(gameView(game, player.ID), i)
+		if !ok {
+			continue
+		}
+
+		raw, _ := json.Marshal(data)
+		if err := gm.applyActionLocked(game, i, action, raw); err != nil {
+			log.Printf("Ошибка хода бота в игре %s: %v", gameID, err)
+			gm.botFailures[gameID]++
+			if gm.botFailures[gameID] <= botRetries {
+				gm.scheduleBots(game)
+				break
+			}
+			log.Printf("Бот в игре %s сдался после %d отклоненных действий", gameID, gm.botFailures[gameID])
+			delete(gm.botFailures, gameID)
+			gm.forfeitLocked(game, i)
+			acted = true
+			break
+		}
+		delete(gm.botFailures, gameID)
+		acted = true
+		break
+	}
+	gm.mutex.Unlock()
+
+	if acted {
+		gm.broadcastGameUpdate(gameID)
+	}
+}
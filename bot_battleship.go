@@ -0,0 +1,176 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// BattleshipBot играет в морской бой по тепловой карте: для каждой клетки
+// считает, сколькими способами в нее помещаются оставшиеся корабли, и бьет
+// в самую вероятную. После попадания учитывает только положения, проходящие
+// через раненый корабль. Бот видит доску противника так же, как человек
+type BattleshipBot struct {
+	randomness float64
+}
+
+func init() {
+	registerBot("battleship", func(difficulty string) (Bot, error) {
+		randomness, err := botRandomness(difficulty)
+		if err != nil {
+			return nil, err
+		}
+		return &BattleshipBot{randomness: randomness}, nil
+	})
+}
+
+func (b *BattleshipBot) Delay() time.Duration {
+	return 900 * time.Millisecond
+}
+
+func (b *BattleshipBot) NextAction(view *Game, playerIndex int) (string, interface{}, bool) {
+	state := view.State.(*BattleshipState)
+
+	switch {
+	case view.Status == "setup" && !state.Boards[playerIndex].Ready:
+		return "placeShips", ShipPlacementData{GameID: view.ID, Random: true}, true
+
+	case view.Status == "playing" && view.Turn == playerIndex:
+		target := &state.Boards[1-playerIndex]
+		if state.Rules.Salvo {
+			shots := min(survivingShips(state.Boards[playerIndex].Ships), unattackedCells(target))
+			return "salvo", SalvoData{GameID: view.ID, Shots: b.chooseTargets(target, state.Rules, shots)}, true
+		}
+		cell := b.chooseTargets(target, state.Rules, 1)[0]
+		return "attack", AttackData{GameID: view.ID, X: cell[0], Y: cell[1]}, true
+	}
+
+	return "", nil, false
+}
+
+// chooseTargets выбирает count разных клеток для выстрелов по доске противника
+func (b *BattleshipBot) chooseTargets(board *Board, rules BattleshipRules, count int) [][2]int {
+	remaining := rules.counts()
+
+	// На доске противника видны только потопленные корабли: их клетки
+	// и соседние клетки, запрещенные правилами касания, другие корабли
+	// содержать не могут
+	sunk, blocked := newFlags(rules.Size), newFlags(rules.Size)
+	for _, ship := range board.Ships {
+		remaining[ship.Length]--
+		for _, cell := range shipCells(ship) {
+			sunk[cell[1]][cell[0]] = true
+			forNeighbours(cell[0], cell[1], rules.Size, func(nx, ny int) {
+				if rules.conflicts(cell, [2]int{nx, ny}) {
+					blocked[ny][nx] = true
+				}
+			})
+		}
+	}
+
+	wounded := newFlags(rules.Size)
+	var free [][2]int
+	targetMode := false
+	for y := range board.Grid {
+		for x, cell := range board.Grid[y] {
+			switch {
+			case cell == "miss":
+				blocked[y][x] = true
+			case cell == "hit" && !sunk[y][x]:
+				wounded[y][x] = true
+				targetMode = true
+			case cell == "":
+				free = append(free, [2]int{x, y})
+			}
+		}
+	}
+
+	heat := make([][]int, rules.Size)
+	for y := range heat {
+		heat[y] = make([]int, rules.Size)
+	}
+	for length, count := range remaining {
+		if count <= 0 {
+			continue
+		}
+		directions := []string{"horizontal", "vertical"}
+		if length == 1 {
+			directions = directions[:1]
+		}
+
+		for y := 0; y < rules.Size; y++ {
+			for x := 0; x < rules.Size; x++ {
+				for _, direction := range directions {
+					cells := shipCells(Ship{X: x, Y: y, Length: length, Direction: direction})
+					covered, ok := placementFits(cells, rules, blocked, wounded)
+					if !ok || (targetMode && covered == 0) {
+						continue
+					}
+
+					weight := count * (1 + 10*covered)
+					for _, cell := range cells {
+						if board.Grid[cell[1]][cell[0]] == "" {
+							heat[cell[1]][cell[0]] += weight
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// Самые горячие клетки идут первыми, среди равных порядок случайный
+	rand.Shuffle(len(free), func(i, j int) { free[i], free[j] = free[j], free[i] })
+	sort.SliceStable(free, func(i, j int) bool {
+		return heat[free[i][1]][free[i][0]] > heat[free[j][1]][free[j][0]]
+	})
+
+	// С вероятностью randomness выстрел уходит в случайную клетку
+	targets := make([][2]int, 0, count)
+	for len(targets) < count && len(free) > 0 {
+		pick := 0
+		if rand.Float64() < b.randomness {
+			pick = rand.Intn(len(free))
+		}
+		targets = append(targets, free[pick])
+		free = append(free[:pick], free[pick+1:]...)
+	}
+	return targets
+}
+
+// placementFits проверяет, может ли корабль стоять в клетках cells, и
+// возвращает, сколько раненых клеток он покрывает. Корабль не может стоять
+// вплотную к чужим раненым клеткам, если правила запрещают касание
+func placementFits(cells [][2]int, rules BattleshipRules, blocked, wounded [][]bool) (int, bool) {
+	inShip := make(map[[2]int]bool, len(cells))
+	covered := 0
+	for _, cell := range cells {
+		x, y := cell[0], cell[1]
+		if !rules.inBounds(x, y) || blocked[y][x] {
+			return 0, false
+		}
+		inShip[cell] = true
+		if wounded[y][x] {
+			covered++
+		}
+	}
+
+	touches := false
+	for _, cell := range cells {
+		forNeighbours(cell[0], cell[1], rules.Size, func(nx, ny int) {
+			neighbour := [2]int{nx, ny}
+			if wounded[ny][nx] && !inShip[neighbour] && rules.conflicts(cell, neighbour) {
+				touches = true
+			}
+		})
+	}
+	return covered, !touches
+}
+
+// newFlags создает пустую карту отметок size x size
+func newFlags(size int) [][]bool {
+	flags := make([][]bool, size)
+	for y := range flags {
+		flags[y] = make([]bool, size)
+	}
+	return flags
+}
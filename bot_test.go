@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// brokenBot всегда отвечает недопустимым ходом
+type brokenBot struct{}
+
+func (brokenBot) NextAction(view *Game, playerIndex int) (string, interface{}, bool) {
+	return "move", MoveData{Row: -1, Col: -1}, view.Turn == playerIndex
+}
+
+func (brokenBot) Delay() time.Duration {
+	return time.Millisecond
+}
+
+func TestBotForfeitsAfterRejectedActions(t *testing.T) {
+	factory := botFactories["tictactoe"]
+	botFactories["tictactoe"] = func(string) (Bot, error) { return brokenBot{}, nil }
+	defer func() { botFactories["tictactoe"] = factory }()
+
+	state, _ := TicTacToeEngine{}.NewState(nil)
+	game := &Game{
+		ID:      "BOTFAIL",
+		Type:    "tictactoe",
+		State:   state,
+		Players: []Player{{ID: "human", Symbol: "X"}, {ID: "bot_1", Symbol: "O", Bot: "easy"}},
+		Turn:    1,
+		Status:  "playing",
+	}
+	gm := &GameManager{
+		games:       map[string]*Game{game.ID: game},
+		botPending:  make(map[string]bool),
+		botFailures: make(map[string]int),
+	}
+
+	gm.mutex.Lock()
+	gm.scheduleBots(game)
+	gm.mutex.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		gm.mutex.RLock()
+		status, winner := game.Status, game.Winner
+		gm.mutex.RUnlock()
+		if status == "finished" {
+			if winner != "X" {
+				t.Errorf("Winner = %q, после сдачи бота должен победить X", winner)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("игра зависла на ходе бота: статус %q", status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+	if gm.botFailures[game.ID] != 0 {
+		t.Errorf("счетчик ошибок бота не сброшен: %d", gm.botFailures[game.ID])
+	}
+}
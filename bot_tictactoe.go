@@ -0,0 +1,213 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// TicTacToeBot играет в крестики-нолики: на поле 3x3 полным перебором
+// (минимакс с альфа-бета отсечением), на больших полях — оценкой рядов.
+// На легких уровнях часть ходов делает наугад
+type TicTacToeBot struct {
+	randomness float64
+}
+
+func init() {
+	registerBot("tictactoe", func(difficulty string) (Bot, error) {
+		randomness, err := botRandomness(difficulty)
+		if err != nil {
+			return nil, err
+		}
+		return &TicTacToeBot{randomness: randomness}, nil
+	})
+}
+
+func (b *TicTacToeBot) Delay() time.Duration {
+	return 600 * time.Millisecond
+}
+
+func (b *TicTacToeBot) NextAction(view *Game, playerIndex int) (string, interface{}, bool) {
+	if view.Status != "playing" || view.Turn != playerIndex {
+		return "", nil, false
+	}
+
+	state := view.State.(*TicTacToeState)
+	me := view.Players[playerIndex].Symbol
+	opponent := view.Players[1-playerIndex].Symbol
+
+	var free [][2]int
+	for row := range state.Board {
+		for col, cell := range state.Board[row] {
+			if cell == "" {
+				free = append(free, [2]int{row, col})
+			}
+		}
+	}
+	if len(free) == 0 {
+		return "", nil, false
+	}
+
+	move := free[rand.Intn(len(free))]
+	if rand.Float64() >= b.randomness {
+		// Полный перебор по силам только на поле 3x3
+		if state.Size == 3 {
+			move = bestTicTacToeMove(copyGrid(state.Board), state.WinLength, me, opponent)
+		} else {
+			move = bestLineMove(state.Board, state.WinLength, me, opponent)
+		}
+	}
+
+	return "move", MoveData{GameID: view.ID, Row: move[0], Col: move[1]}, true
+}
+
+// bestTicTacToeMove выбирает лучший ход; из равноценных — случайный
+func bestTicTacToeMove(board [][]string, winLength int, me, opponent string) [2]int {
+	bestScore := -100
+	var best [][2]int
+	for row := range board {
+		for col := range board[row] {
+			if board[row][col] != "" {
+				continue
+			}
+			board[row][col] = me
+			score := -ticTacToeMinimax(board, winLength, opponent, me, row, col, 1, -100, 100)
+			board[row][col] = ""
+
+			if score > bestScore {
+				bestScore = score
+				best = best[:0]
+			}
+			if score == bestScore {
+				best = append(best, [2]int{row, col})
+			}
+		}
+	}
+	return best[rand.Intn(len(best))]
+}
+
+// ticTacToeMinimax оценивает позицию для toMove (негамакс) после хода other
+// в клетку (row, col): выигрыш тем дороже, чем он ближе, проигрыш тем
+// дешевле, чем он дальше
+func ticTacToeMinimax(board [][]string, winLength int, toMove, other string, row, col, depth, alpha, beta int) int {
+	if checkWinnerTicTacToe(board, winLength, row, col) == other {
+		return depth - 10
+	}
+	if isBoardFull(board) {
+		return 0
+	}
+
+	for r := range board {
+		for c := range board[r] {
+			if board[r][c] != "" {
+				continue
+			}
+			board[r][c] = toMove
+			score := -ticTacToeMinimax(board, winLength, other, toMove, r, c, depth+1, -beta, -alpha)
+			board[r][c] = ""
+
+			if score > alpha {
+				alpha = score
+			}
+			if alpha >= beta {
+				return alpha
+			}
+		}
+	}
+	return alpha
+}
+
+// bestLineMove выбирает ход на большом поле без перебора: каждая свободная
+// клетка рядом с занятыми оценивается по тому, какие свои ряды она продлевает
+// (атака) и какие ряды противника обрывает (защита)
+func bestLineMove(board [][]string, winLength int, me, opponent string) [2]int {
+	size := len(board)
+	bestScore := -1
+	var best [][2]int
+
+	for row := range board {
+		for col := range board[row] {
+			if board[row][col] != "" || !hasNeighbourStone(board, row, col) {
+				continue
+			}
+
+			score := 10*lineScore(board, winLength, row, col, me) + 9*lineScore(board, winLength, row, col, opponent)
+			if score > bestScore {
+				bestScore = score
+				best = best[:0]
+			}
+			if score == bestScore {
+				best = append(best, [2]int{row, col})
+			}
+		}
+	}
+
+	// Пустое поле — начинаем с центра
+	if len(best) == 0 {
+		return [2]int{size / 2, size / 2}
+	}
+	return best[rand.Intn(len(best))]
+}
+
+// lineScore оценивает, насколько ход symbol в клетку (row, col) усиливает его ряды
+func lineScore(board [][]string, winLength, row, col int, symbol string) int {
+	total := 0
+	for _, direction := range ticTacToeDirections {
+		dr, dc := direction[0], direction[1]
+		forward := countInRow(board, row, col, dr, dc, symbol)
+		backward := countInRow(board, row, col, -dr, -dc, symbol)
+		count := 1 + forward + backward
+		if count >= winLength {
+			return 1_000_000
+		}
+
+		// Ряд, который не может дорасти до winLength, бесполезен
+		room := 1 + countFree(board, row, col, dr, dc, symbol) + countFree(board, row, col, -dr, -dc, symbol)
+		if room < winLength {
+			continue
+		}
+
+		open := 0
+		if isEmptyCell(board, row+dr*(forward+1), col+dc*(forward+1)) {
+			open++
+		}
+		if isEmptyCell(board, row-dr*(backward+1), col-dc*(backward+1)) {
+			open++
+		}
+
+		value := 1
+		for i := 0; i < count; i++ {
+			value *= 10
+		}
+		total += value * (open + 1)
+	}
+	return total
+}
+
+// countFree считает клетки подряд от (row, col) в направлении (dr, dc), не занятые противником symbol
+func countFree(board [][]string, row, col, dr, dc int, symbol string) int {
+	count := 0
+	for r, c := row+dr, col+dc; r >= 0 && r < len(board) && c >= 0 && c < len(board[r]); r, c = r+dr, c+dc {
+		if board[r][c] != "" && board[r][c] != symbol {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// isEmptyCell проверяет, что клетка на поле и свободна
+func isEmptyCell(board [][]string, row, col int) bool {
+	return row >= 0 && row < len(board) && col >= 0 && col < len(board[row]) && board[row][col] == ""
+}
+
+// hasNeighbourStone проверяет, есть ли занятые клетки на расстоянии до 2 от (row, col)
+func hasNeighbourStone(board [][]string, row, col int) bool {
+	for r := row - 2; r <= row+2; r++ {
+		for c := col - 2; c <= col+2; c++ {
+			if r >= 0 && r < len(board) && c >= 0 && c < len(board[r]) && board[r][c] != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
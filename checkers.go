@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CheckersState состояние партии в русские шашки. Белые (игрок 0) начинают
+// снизу и ходят первыми. Шашки обозначаются "w" и "b", дамки — "W" и "B"
+type CheckersState struct {
+	Board      [][]string     `json:"board"`                // Board[row][col], строка 0 — сторона черных
+	LastMove   *CheckersMove  `json:"lastMove,omitempty"`   // Последний ход
+	LegalMoves []CheckersMove `json:"legalMoves,omitempty"` // Ходы стороны, чья очередь; заполняется только в представлении
+}
+
+// CheckersMove ход шашкой: клетки, через которые она прошла, от начальной до конечной
+type CheckersMove struct {
+	Path     [][2]int `json:"path"`               // Клетки (row, col)
+	Captures [][2]int `json:"captures,omitempty"` // Побитые шашки
+	Promotes bool     `json:"promotes,omitempty"` // Шашка становится дамкой
+}
+
+// CheckersMoveData для хода в шашки
+type CheckersMoveData struct {
+	GameID   string   `json:"gameId"`
+	Path     [][2]int `json:"path"`
+	PlayerID string   `json:"playerId"`
+}
+
+const checkersSize = 8
+
+// checkersDirections диагональные направления (dr, dc)
+var checkersDirections = [4][2]int{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+
+// CheckersEngine правила русских шашек
+type CheckersEngine struct{}
+
+func init() {
+	registerEngine("checkers", CheckersEngine{})
+}
+
+func (CheckersEngine) NewState(options json.RawMessage) (interface{}, error) {
+	board := newGrid(checkersSize)
+	for row := 0; row < checkersSize; row++ {
+		for col := 0; col < checkersSize; col++ {
+			if (row+col)%2 == 0 {
+				continue
+			}
+			switch {
+			case row < 3:
+				board[row][col] = "b"
+			case row > 4:
+				board[row][col] = "w"
+			}
+		}
+	}
+	return &CheckersState{Board: board}, nil
+}
+
+func (CheckersEngine) Symbol(playerIndex int) string {
+	if playerIndex == 0 {
+		return "white"
+	}
+	return "black"
+}
+
+func (CheckersEngine) StartStatus() string {
+	return "playing"
+}
+
+func (e CheckersEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	if action != "move" {
+		return fmt.Errorf("неизвестное действие")
+	}
+
+	var move CheckersMoveData
+	if err := json.Unmarshal(data, &move); err != nil {
+		return fmt.Errorf("некорректный ход")
+	}
+	return e.makeMove(game, playerIndex, move.Path)
+}
+
+// makeMove делает ход, если он есть среди допустимых
+func (CheckersEngine) makeMove(game *Game, playerIndex int, path [][2]int) error {
+	state := game.State.(*CheckersState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	moves := checkersMoves(state.Board, checkersSide(playerIndex))
+	index := slices.IndexFunc(moves, func(move CheckersMove) bool {
+		return slices.Equal(move.Path, path)
+	})
+	if index == -1 {
+		if len(moves) > 0 && len(moves[0].Captures) > 0 {
+			return fmt.Errorf("недопустимый ход: бить обязательно")
+		}
+		return fmt.Errorf("недопустимый ход")
+	}
+	move := moves[index]
+
+	from, to := move.Path[0], move.Path[len(move.Path)-1]
+	piece := state.Board[from[0]][from[1]]
+	state.Board[from[0]][from[1]] = ""
+	for _, captured := range move.Captures {
+		state.Board[captured[0]][captured[1]] = ""
+	}
+	if move.Promotes {
+		piece = strings.ToUpper(piece)
+	}
+	state.Board[to[0]][to[1]] = piece
+	state.LastMove = &move
+
+	game.Turn = 1 - game.Turn
+	return nil
+}
+
+// Outcome: проигрывает сторона, у которой не осталось шашек или ходов
+func (e CheckersEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*CheckersState)
+	if len(checkersMoves(state.Board, checkersSide(game.Turn))) == 0 {
+		return true, e.Symbol(1 - game.Turn)
+	}
+	return false, ""
+}
+
+func (CheckersEngine) View(game *Game, playerIndex int) interface{} {
+	state := *game.State.(*CheckersState)
+	state.Board = copyGrid(state.Board)
+	if game.Status == "playing" {
+		state.LegalMoves = checkersMoves(state.Board, checkersSide(game.Turn))
+	}
+	return &state
+}
+
+func (e CheckersEngine) Reset(game *Game) {
+	game.State, _ = e.NewState(game.Options)
+}
+
+// checkersSide возвращает обозначение шашек игрока: "w" или "b"
+func checkersSide(playerIndex int) string {
+	if playerIndex == 0 {
+		return "w"
+	}
+	return "b"
+}
+
+// checkersMoves возвращает все допустимые ходы стороны side. Если можно
+// бить, допустимы только взятия
+func checkersMoves(board [][]string, side string) []CheckersMove {
+	var captures, quiet []CheckersMove
+	for row := range board {
+		for col, piece := range board[row] {
+			if strings.ToLower(piece) != side {
+				continue
+			}
+			from := [2]int{row, col}
+			king := piece != side
+
+			// Во время взятия начальная клетка свободна
+			board[row][col] = ""
+			for _, move := range checkersCaptures(board, side, king, [][2]int{from}, nil) {
+				// Шашка, прошедшая через последний ряд, становится дамкой
+				move.Promotes = !king && slices.ContainsFunc(move.Path[1:], func(square [2]int) bool {
+					return square[0] == checkersPromotionRow(side)
+				})
+				captures = append(captures, move)
+			}
+			board[row][col] = piece
+
+			if len(captures) == 0 {
+				quiet = append(quiet, checkersQuietMoves(board, side, king, from)...)
+			}
+		}
+	}
+
+	if len(captures) > 0 {
+		return captures
+	}
+	return quiet
+}
+
+// checkersQuietMoves возвращает ходы без взятия: шашка ходит на одну клетку
+// вперед, дамка — на любое расстояние по свободной диагонали
+func checkersQuietMoves(board [][]string, side string, king bool, from [2]int) []CheckersMove {
+	var moves []CheckersMove
+	for _, direction := range checkersDirections {
+		if !king && direction[0] != checkersForward(side) {
+			continue
+		}
+		for r, c := from[0]+direction[0], from[1]+direction[1]; checkersOnBoard(r, c) && board[r][c] == ""; r, c = r+direction[0], c+direction[1] {
+			moves = append(moves, CheckersMove{
+				Path:     [][2]int{from, {r, c}},
+				Promotes: !king && r == checkersPromotionRow(side),
+			})
+			if !king {
+				break
+			}
+		}
+	}
+	return moves
+}
+
+// checkersCaptures продолжает взятие с последней клетки path и возвращает
+// все законченные последовательности. Побитые шашки снимаются с доски только
+// после хода, поэтому перепрыгнуть их повторно нельзя. Шашка, дошедшая до
+// последнего ряда, продолжает бить уже как дамка
+func checkersCaptures(board [][]string, side string, king bool, path, captured [][2]int) []CheckersMove {
+	from := path[len(path)-1]
+	var moves []CheckersMove
+
+	for _, direction := range checkersDirections {
+		target, landings := checkersJump(board, side, king, from, direction, captured)
+		if landings == nil {
+			continue
+		}
+
+		nextCaptured := append(slices.Clone(captured), target)
+
+		// Если с какого-то поля взятие можно продолжить, дамка обязана встать на такое поле
+		var continuing [][2]int
+		for _, landing := range landings {
+			promoted := king || landing[0] == checkersPromotionRow(side)
+			if checkersCanCapture(board, side, promoted, landing, nextCaptured) {
+				continuing = append(continuing, landing)
+			}
+		}
+		if len(continuing) > 0 {
+			landings = continuing
+		}
+
+		for _, landing := range landings {
+			promoted := king || landing[0] == checkersPromotionRow(side)
+			nextPath := append(slices.Clone(path), landing)
+			if len(continuing) > 0 {
+				moves = append(moves, checkersCaptures(board, side, promoted, nextPath, nextCaptured)...)
+				continue
+			}
+			moves = append(moves, CheckersMove{Path: nextPath, Captures: nextCaptured})
+		}
+	}
+	return moves
+}
+
+// checkersJump ищет взятие из from в направлении direction: возвращает
+// побиваемую шашку и клетки, на которые можно встать после нее
+func checkersJump(board [][]string, side string, king bool, from, direction [2]int, captured [][2]int) ([2]int, [][2]int) {
+	r, c := from[0]+direction[0], from[1]+direction[1]
+	if king {
+		// Дамка подходит к шашке противника по свободной диагонали
+		for checkersOnBoard(r, c) && board[r][c] == "" {
+			r, c = r+direction[0], c+direction[1]
+		}
+	}
+	if !checkersOnBoard(r, c) || board[r][c] == "" || strings.ToLower(board[r][c]) == side {
+		return [2]int{}, nil
+	}
+	target := [2]int{r, c}
+	if slices.Contains(captured, target) {
+		return [2]int{}, nil
+	}
+
+	var landings [][2]int
+	for r, c = r+direction[0], c+direction[1]; checkersOnBoard(r, c) && board[r][c] == ""; r, c = r+direction[0], c+direction[1] {
+		landings = append(landings, [2]int{r, c})
+		if !king {
+			break
+		}
+	}
+	return target, landings
+}
+
+// checkersCanCapture проверяет, может ли шашка с поля from бить дальше
+func checkersCanCapture(board [][]string, side string, king bool, from [2]int, captured [][2]int) bool {
+	for _, direction := range checkersDirections {
+		if _, landings := checkersJump(board, side, king, from, direction, captured); landings != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkersForward направление движения шашек стороны по строкам
+func checkersForward(side string) int {
+	if side == "w" {
+		return -1
+	}
+	return 1
+}
+
+// checkersPromotionRow ряд, на котором шашки стороны становятся дамками
+func checkersPromotionRow(side string) int {
+	if side == "w" {
+		return 0
+	}
+	return checkersSize - 1
+}
+
+func checkersOnBoard(row, col int) bool {
+	return row >= 0 && row < checkersSize && col >= 0 && col < checkersSize
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// checkersBoard создает доску с шашками pieces: ключ — клетка (row, col)
+func checkersBoard(pieces map[[2]int]string) [][]string {
+	board := newGrid(checkersSize)
+	for square, piece := range pieces {
+		board[square[0]][square[1]] = piece
+	}
+	return board
+}
+
+// findCheckersMove находит ход с путем path
+func findCheckersMove(moves []CheckersMove, path ...[2]int) (CheckersMove, bool) {
+	index := slices.IndexFunc(moves, func(move CheckersMove) bool {
+		return slices.Equal(move.Path, path)
+	})
+	if index == -1 {
+		return CheckersMove{}, false
+	}
+	return moves[index], true
+}
+
+func TestCheckersMandatoryCapture(t *testing.T) {
+	board := checkersBoard(map[[2]int]string{
+		{5, 2}: "w",
+		{5, 6}: "w",
+		{4, 3}: "b",
+	})
+
+	moves := checkersMoves(board, "w")
+	if len(moves) != 1 {
+		t.Fatalf("checkersMoves() = %v, ожидалось одно взятие", moves)
+	}
+	move, ok := findCheckersMove(moves, [2]int{5, 2}, [2]int{3, 4})
+	if !ok || !slices.Equal(move.Captures, [][2]int{{4, 3}}) {
+		t.Errorf("checkersMoves() = %v, ожидалось взятие 5,2 → 3,4", moves)
+	}
+
+	game := &Game{State: &CheckersState{Board: board}, Status: "playing"}
+	if err := (CheckersEngine{}).makeMove(game, 0, [][2]int{{5, 6}, {4, 7}}); err == nil {
+		t.Error("makeMove() разрешил тихий ход, когда можно бить")
+	}
+}
+
+func TestCheckersMultiJump(t *testing.T) {
+	// Шашка бьет две шашки подряд, в том числе назад
+	board := checkersBoard(map[[2]int]string{
+		{4, 1}: "w",
+		{3, 2}: "b",
+		{3, 4}: "b",
+	})
+	game := &Game{State: &CheckersState{Board: board}, Status: "playing"}
+
+	path := [][2]int{{4, 1}, {2, 3}, {4, 5}}
+	if err := (CheckersEngine{}).makeMove(game, 0, path); err != nil {
+		t.Fatalf("makeMove(%v): %v", path, err)
+	}
+
+	// Обе побитые шашки снимаются после хода
+	for square, want := range map[[2]int]string{{4, 1}: "", {3, 2}: "", {3, 4}: "", {4, 5}: "w"} {
+		if got := board[square[0]][square[1]]; got != want {
+			t.Errorf("клетка %v = %q, ожидалось %q", square, got, want)
+		}
+	}
+	if game.Turn != 1 {
+		t.Errorf("Turn = %d, ход должен перейти к черным", game.Turn)
+	}
+}
+
+func TestCheckersCapturedPiecesStayUntilMoveEnds(t *testing.T) {
+	// Дамка бьет 4,3, затем 3,6 и 5,6. С 6,5 ее путь к шашке 2,1 проходит
+	// через уже побитую 4,3: до конца хода она остается на доске и закрывает
+	// диагональ («турецкий удар»)
+	pieces := map[[2]int]string{
+		{6, 1}: "W",
+		{4, 3}: "b",
+		{3, 6}: "b",
+		{5, 6}: "b",
+		{2, 1}: "b",
+	}
+
+	moves := checkersMoves(checkersBoard(pieces), "w")
+	move, ok := findCheckersMove(moves, [2]int{6, 1}, [2]int{2, 5}, [2]int{4, 7}, [2]int{6, 5})
+	if !ok {
+		t.Fatalf("checkersMoves() = %v, нет взятия через 2,5, 4,7 и 6,5", moves)
+	}
+	if !slices.Equal(move.Captures, [][2]int{{4, 3}, {3, 6}, {5, 6}}) {
+		t.Errorf("Captures = %v", move.Captures)
+	}
+	for _, move := range moves {
+		if slices.Contains(move.Captures, [2]int{2, 1}) {
+			t.Errorf("дамка перепрыгнула побитую шашку: %v", move)
+		}
+	}
+
+	// Без шашки 4,3 та же дамка с 6,5 бьет 2,1
+	delete(pieces, [2]int{4, 3})
+	pieces[[2]int{6, 5}] = "W"
+	delete(pieces, [2]int{6, 1})
+	if _, ok := findCheckersMove(checkersMoves(checkersBoard(pieces), "w"), [2]int{6, 5}, [2]int{1, 0}); !ok {
+		t.Error("без побитой шашки диагональ 6,5 — 1,0 должна быть открыта")
+	}
+}
+
+func TestCheckersKingMustLandToContinue(t *testing.T) {
+	// После взятия 5,2 дамка может встать на 4,3 … 0,7, но бить дальше
+	// можно только с 4,3 — остальные поля недопустимы
+	board := checkersBoard(map[[2]int]string{
+		{7, 0}: "W",
+		{5, 2}: "b",
+		{2, 1}: "b",
+	})
+
+	moves := checkersMoves(board, "w")
+	if len(moves) != 1 {
+		t.Fatalf("checkersMoves() = %v, ожидалось одно взятие", moves)
+	}
+	move := moves[0]
+	if !slices.Equal(move.Path, [][2]int{{7, 0}, {4, 3}, {1, 0}}) {
+		t.Errorf("Path = %v, ожидалось 7,0 → 4,3 → 1,0", move.Path)
+	}
+	if !slices.Equal(move.Captures, [][2]int{{5, 2}, {2, 1}}) {
+		t.Errorf("Captures = %v", move.Captures)
+	}
+}
+
+func TestCheckersPromotionMidCapture(t *testing.T) {
+	// Шашка бьет 1,2 и встает на последний ряд. Простой шашкой с 0,3 бить
+	// уже нечего, а дамкой — можно: взятие продолжается по диагонали до 2,5
+	board := checkersBoard(map[[2]int]string{
+		{2, 1}: "w",
+		{1, 2}: "b",
+		{2, 5}: "b",
+	})
+
+	moves := checkersMoves(board, "w")
+	if len(moves) != 2 {
+		t.Fatalf("checkersMoves() = %v, ожидалось два окончания взятия", moves)
+	}
+	for _, landing := range [][2]int{{3, 6}, {4, 7}} {
+		move, ok := findCheckersMove(moves, [2]int{2, 1}, [2]int{0, 3}, landing)
+		if !ok {
+			t.Errorf("нет взятия с окончанием на %v: %v", landing, moves)
+			continue
+		}
+		if !move.Promotes {
+			t.Errorf("ход %v должен превращать шашку в дамку", move.Path)
+		}
+		if !slices.Equal(move.Captures, [][2]int{{1, 2}, {2, 5}}) {
+			t.Errorf("Captures = %v", move.Captures)
+		}
+	}
+
+	game := &Game{State: &CheckersState{Board: board}, Status: "playing"}
+	if err := (CheckersEngine{}).makeMove(game, 0, [][2]int{{2, 1}, {0, 3}, {4, 7}}); err != nil {
+		t.Fatal(err)
+	}
+	if board[4][7] != "W" {
+		t.Errorf("на 4,7 %q, ожидалась дамка", board[4][7])
+	}
+}
@@ -0,0 +1,801 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// chessStartFEN начальная позиция
+const chessStartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// ChessState состояние шахматной партии. Позиция хранится в FEN, доска
+// и список ходов для клиента строятся из нее в представлении
+type ChessState struct {
+	FEN        string            `json:"fen"`
+	Moves      []ChessMoveRecord `json:"moves"`                // Сделанные ходы
+	Positions  []string          `json:"positions"`            // Позиции (FEN без счетчиков) от начальной, для троекратного повторения
+	Result     string            `json:"result,omitempty"`     // "checkmate", "stalemate", "threefold", "fifty-move" или "insufficient"
+	Board      [][]string        `json:"board,omitempty"`      // Board[0] — восьмая горизонталь; только в представлении
+	Check      bool              `json:"check,omitempty"`      // Королю стороны, чья очередь, объявлен шах; только в представлении
+	LegalMoves []string          `json:"legalMoves,omitempty"` // Допустимые ходы в UCI; только в представлении
+}
+
+// ChessMoveRecord ход в двух нотациях
+type ChessMoveRecord struct {
+	UCI string `json:"uci"`
+	SAN string `json:"san"`
+}
+
+// ChessMoveData для хода в шахматы: в UCI ("e2e4", "e7e8q") или SAN ("Nf3", "O-O")
+type ChessMoveData struct {
+	GameID   string `json:"gameId"`
+	Move     string `json:"move"`
+	PlayerID string `json:"playerId"`
+}
+
+// ChessEngine правила шахмат
+type ChessEngine struct{}
+
+func init() {
+	registerEngine("chess", ChessEngine{})
+}
+
+func (ChessEngine) NewState(options json.RawMessage) (interface{}, error) {
+	position, _ := parseFEN(chessStartFEN)
+	return &ChessState{
+		FEN:       chessStartFEN,
+		Moves:     []ChessMoveRecord{},
+		Positions: []string{position.key()},
+	}, nil
+}
+
+func (ChessEngine) Symbol(playerIndex int) string {
+	if playerIndex == 0 {
+		return "white"
+	}
+	return "black"
+}
+
+func (ChessEngine) StartStatus() string {
+	return "playing"
+}
+
+func (e ChessEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	if action != "move" {
+		return fmt.Errorf("неизвестное действие")
+	}
+
+	var move ChessMoveData
+	if err := json.Unmarshal(data, &move); err != nil {
+		return fmt.Errorf("некорректный ход")
+	}
+	return e.makeMove(game, playerIndex, move.Move)
+}
+
+// makeMove делает ход и проверяет, не закончилась ли партия
+func (ChessEngine) makeMove(game *Game, playerIndex int, text string) error {
+	state := game.State.(*ChessState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	position, err := parseFEN(state.FEN)
+	if err != nil {
+		return err
+	}
+
+	legal := position.legalMoves()
+	move, err := position.parseMove(text, legal)
+	if err != nil {
+		return err
+	}
+
+	record := ChessMoveRecord{UCI: move.uci(), SAN: position.san(move, legal)}
+	next := position.play(move)
+
+	// Ключ позиции перебирает все ходы из-за взятия на проходе: считаем его один раз
+	key := next.key()
+	state.FEN = fmt.Sprintf("%s %d %d", key, next.halfmove, next.fullmove)
+	state.Moves = append(state.Moves, record)
+	state.Positions = append(state.Positions, key)
+
+	repetitions := 0
+	for _, seen := range state.Positions {
+		if seen == key {
+			repetitions++
+		}
+	}
+
+	replies := len(next.legalMoves())
+	switch {
+	case replies == 0 && next.inCheck():
+		state.Result = "checkmate"
+	case replies == 0:
+		state.Result = "stalemate"
+	case repetitions >= 3:
+		state.Result = "threefold"
+	case next.halfmove >= 100:
+		state.Result = "fifty-move"
+	case next.insufficientMaterial():
+		state.Result = "insufficient"
+	default:
+		game.Turn = 1 - game.Turn
+	}
+	return nil
+}
+
+func (e ChessEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*ChessState)
+	switch state.Result {
+	case "":
+		return false, ""
+	case "checkmate":
+		// Ход после мата не передается: победил тот, чья очередь
+		return true, e.Symbol(game.Turn)
+	}
+	return true, "draw"
+}
+
+func (ChessEngine) View(game *Game, playerIndex int) interface{} {
+	state := *game.State.(*ChessState)
+	state.Moves = append([]ChessMoveRecord{}, state.Moves...)
+	state.Positions = nil
+
+	position, err := parseFEN(state.FEN)
+	if err != nil {
+		return &state
+	}
+
+	state.Board = newGrid(8)
+	for square, piece := range position.board {
+		if piece != 0 {
+			state.Board[7-square/8][square%8] = string(piece)
+		}
+	}
+	state.Check = position.inCheck()
+	if game.Status == "playing" {
+		for _, move := range position.legalMoves() {
+			state.LegalMoves = append(state.LegalMoves, move.uci())
+		}
+	}
+	return &state
+}
+
+func (e ChessEngine) Reset(game *Game) {
+	game.State, _ = e.NewState(game.Options)
+}
+
+// chessPosition позиция на доске. Поля нумеруются от a1 (0) до h8 (63)
+type chessPosition struct {
+	board     [64]byte // Фигуры в обозначениях FEN, 0 — пустое поле
+	white     bool     // Очередь белых
+	castling  string   // Права на рокировку: подмножество "KQkq"
+	enPassant int      // Поле взятия на проходе, -1 — нет
+	halfmove  int      // Полуходы без взятий и ходов пешками
+	fullmove  int
+}
+
+// chessMove ход с поля from на поле to
+type chessMove struct {
+	from, to  int
+	promotion byte // Фигура превращения в нижнем регистре, 0 — нет
+	enPassant bool
+	castle    bool
+}
+
+var (
+	knightSteps = [8][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingSteps   = [8][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	rookRays    = [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopRays  = [4][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+)
+
+// parseFEN читает позицию из FEN
+func parseFEN(fen string) (*chessPosition, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("некорректная позиция")
+	}
+
+	p := &chessPosition{white: fields[1] == "w", castling: fields[2], enPassant: -1}
+	if p.castling == "-" {
+		p.castling = ""
+	}
+
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("некорректная позиция")
+	}
+	for i, rank := range ranks {
+		file := 0
+		for _, char := range []byte(rank) {
+			if char >= '1' && char <= '8' {
+				file += int(char - '0')
+				continue
+			}
+			if file > 7 {
+				return nil, fmt.Errorf("некорректная позиция")
+			}
+			p.board[(7-i)*8+file] = char
+			file++
+		}
+	}
+
+	if fields[3] != "-" {
+		p.enPassant = parseSquare(fields[3])
+	}
+	fmt.Sscan(fields[4], &p.halfmove)
+	fmt.Sscan(fields[5], &p.fullmove)
+	return p, nil
+}
+
+// fen записывает позицию в FEN
+func (p *chessPosition) fen() string {
+	return fmt.Sprintf("%s %d %d", p.key(), p.halfmove, p.fullmove)
+}
+
+// key возвращает FEN без счетчиков ходов: одинаковые позиции дают одинаковый ключ
+func (p *chessPosition) key() string {
+	var b strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			piece := p.board[rank*8+file]
+			if piece == 0 {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				b.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			b.WriteByte(piece)
+		}
+		if empty > 0 {
+			b.WriteByte(byte('0' + empty))
+		}
+		if rank > 0 {
+			b.WriteByte('/')
+		}
+	}
+
+	side := "b"
+	if p.white {
+		side = "w"
+	}
+	castling := p.castling
+	if castling == "" {
+		castling = "-"
+	}
+	enPassant := "-"
+	if p.enPassant >= 0 && p.enPassantCapturable() {
+		enPassant = squareName(p.enPassant)
+	}
+	return fmt.Sprintf("%s %s %s %s", b.String(), side, castling, enPassant)
+}
+
+// enPassantCapturable проверяет, может ли какая-нибудь пешка взять на проходе:
+// иначе поле не влияет на повторение позиции
+func (p *chessPosition) enPassantCapturable() bool {
+	for _, move := range p.legalMoves() {
+		if move.enPassant {
+			return true
+		}
+	}
+	return false
+}
+
+// own проверяет, что фигура принадлежит стороне, чья очередь
+func (p *chessPosition) own(piece byte) bool {
+	return piece != 0 && isWhitePiece(piece) == p.white
+}
+
+// pseudoMoves возвращает ходы без проверки, остается ли король под шахом
+func (p *chessPosition) pseudoMoves() []chessMove {
+	var moves []chessMove
+	for from, piece := range p.board {
+		if !p.own(piece) {
+			continue
+		}
+		file, rank := from%8, from/8
+
+		switch lowerPiece(piece) {
+		case 'p':
+			moves = append(moves, p.pawnMoves(from)...)
+		case 'n':
+			moves = append(moves, p.stepMoves(from, knightSteps[:])...)
+		case 'k':
+			moves = append(moves, p.stepMoves(from, kingSteps[:])...)
+			moves = append(moves, p.castlingMoves(from)...)
+		default:
+			var rays [][2]int
+			if lowerPiece(piece) != 'b' {
+				rays = append(rays, rookRays[:]...)
+			}
+			if lowerPiece(piece) != 'r' {
+				rays = append(rays, bishopRays[:]...)
+			}
+			for _, ray := range rays {
+				for f, r := file+ray[0], rank+ray[1]; onChessBoard(f, r); f, r = f+ray[0], r+ray[1] {
+					target := p.board[r*8+f]
+					if p.own(target) {
+						break
+					}
+					moves = append(moves, chessMove{from: from, to: r*8 + f})
+					if target != 0 {
+						break
+					}
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// pawnMoves ходы пешки: вперед на одно или два поля, взятия, взятие на проходе и превращение
+func (p *chessPosition) pawnMoves(from int) []chessMove {
+	file, rank := from%8, from/8
+	forward, startRank, lastRank := 1, 1, 7
+	if !p.white {
+		forward, startRank, lastRank = -1, 6, 0
+	}
+
+	var moves []chessMove
+	add := func(to int, enPassant bool) {
+		if to/8 == lastRank {
+			for _, promotion := range []byte("qrbn") {
+				moves = append(moves, chessMove{from: from, to: to, promotion: promotion})
+			}
+			return
+		}
+		moves = append(moves, chessMove{from: from, to: to, enPassant: enPassant})
+	}
+
+	if one := from + 8*forward; p.board[one] == 0 {
+		add(one, false)
+		if two := one + 8*forward; rank == startRank && p.board[two] == 0 {
+			add(two, false)
+		}
+	}
+
+	for _, side := range []int{-1, 1} {
+		f, r := file+side, rank+forward
+		if !onChessBoard(f, r) {
+			continue
+		}
+		to := r*8 + f
+		target := p.board[to]
+		switch {
+		case target != 0 && !p.own(target):
+			add(to, false)
+		case target == 0 && to == p.enPassant:
+			add(to, true)
+		}
+	}
+	return moves
+}
+
+// stepMoves ходы фигуры, которая делает один шаг (конь, король)
+func (p *chessPosition) stepMoves(from int, steps [][2]int) []chessMove {
+	var moves []chessMove
+	for _, step := range steps {
+		f, r := from%8+step[0], from/8+step[1]
+		if onChessBoard(f, r) && !p.own(p.board[r*8+f]) {
+			moves = append(moves, chessMove{from: from, to: r*8 + f})
+		}
+	}
+	return moves
+}
+
+// castlingMoves рокировки: король не под шахом и не проходит через битые поля
+func (p *chessPosition) castlingMoves(from int) []chessMove {
+	rights, home := "KQ", 4
+	if !p.white {
+		rights, home = "kq", 60
+	}
+	if from != home || p.attacked(home, !p.white) {
+		return nil
+	}
+
+	var moves []chessMove
+	if strings.IndexByte(p.castling, rights[0]) >= 0 &&
+		p.board[home+1] == 0 && p.board[home+2] == 0 &&
+		!p.attacked(home+1, !p.white) && !p.attacked(home+2, !p.white) {
+		moves = append(moves, chessMove{from: home, to: home + 2, castle: true})
+	}
+	if strings.IndexByte(p.castling, rights[1]) >= 0 &&
+		p.board[home-1] == 0 && p.board[home-2] == 0 && p.board[home-3] == 0 &&
+		!p.attacked(home-1, !p.white) && !p.attacked(home-2, !p.white) {
+		moves = append(moves, chessMove{from: home, to: home - 2, castle: true})
+	}
+	return moves
+}
+
+// attacked проверяет, бьет ли сторона byWhite поле square
+func (p *chessPosition) attacked(square int, byWhite bool) bool {
+	file, rank := square%8, square/8
+	is := func(f, r int, pieces string) bool {
+		if !onChessBoard(f, r) {
+			return false
+		}
+		piece := p.board[r*8+f]
+		return piece != 0 && isWhitePiece(piece) == byWhite && strings.IndexByte(pieces, lowerPiece(piece)) >= 0
+	}
+
+	// Пешки бьют по диагонали вперед, значит, атакуют поле сзади-сбоку
+	pawnRank := rank - 1
+	if !byWhite {
+		pawnRank = rank + 1
+	}
+	if is(file-1, pawnRank, "p") || is(file+1, pawnRank, "p") {
+		return true
+	}
+
+	for _, step := range knightSteps {
+		if is(file+step[0], rank+step[1], "n") {
+			return true
+		}
+	}
+	for _, step := range kingSteps {
+		if is(file+step[0], rank+step[1], "k") {
+			return true
+		}
+	}
+
+	sliders := []struct {
+		rays   [4][2]int
+		pieces string
+	}{{rookRays, "rq"}, {bishopRays, "bq"}}
+	for _, slider := range sliders {
+		for _, ray := range slider.rays {
+			for f, r := file+ray[0], rank+ray[1]; onChessBoard(f, r); f, r = f+ray[0], r+ray[1] {
+				if p.board[r*8+f] == 0 {
+					continue
+				}
+				if is(f, r, slider.pieces) {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+// play возвращает позицию после хода
+func (p *chessPosition) play(move chessMove) *chessPosition {
+	next := *p
+	piece := next.board[move.from]
+	capture := next.board[move.to] != 0 || move.enPassant
+
+	next.board[move.to] = piece
+	next.board[move.from] = 0
+
+	if move.enPassant {
+		next.board[move.from/8*8+move.to%8] = 0
+	}
+	if move.promotion != 0 {
+		next.board[move.to] = move.promotion
+		if p.white {
+			next.board[move.to] = move.promotion - 'a' + 'A'
+		}
+	}
+	if move.castle {
+		// Ладья перепрыгивает через короля
+		if move.to > move.from {
+			next.board[move.from+1], next.board[move.from+3] = next.board[move.from+3], 0
+		} else {
+			next.board[move.from-1], next.board[move.from-4] = next.board[move.from-4], 0
+		}
+	}
+
+	// Король или ладья сдвинулись с места — права на рокировку теряются
+	lost := map[int]string{4: "KQ", 60: "kq", 0: "Q", 7: "K", 56: "q", 63: "k"}
+	for _, square := range []int{move.from, move.to} {
+		for _, right := range lost[square] {
+			next.castling = strings.ReplaceAll(next.castling, string(right), "")
+		}
+	}
+
+	next.enPassant = -1
+	if lowerPiece(piece) == 'p' && (move.to-move.from == 16 || move.from-move.to == 16) {
+		next.enPassant = (move.from + move.to) / 2
+	}
+
+	next.halfmove++
+	if capture || lowerPiece(piece) == 'p' {
+		next.halfmove = 0
+	}
+	if !p.white {
+		next.fullmove++
+	}
+	next.white = !p.white
+	return &next
+}
+
+// kingSquare поле короля стороны white
+func (p *chessPosition) kingSquare(white bool) int {
+	king := byte('k')
+	if white {
+		king = 'K'
+	}
+	for square, piece := range p.board {
+		if piece == king {
+			return square
+		}
+	}
+	return -1
+}
+
+// inCheck проверяет, объявлен ли шах стороне, чья очередь
+func (p *chessPosition) inCheck() bool {
+	king := p.kingSquare(p.white)
+	return king >= 0 && p.attacked(king, !p.white)
+}
+
+// legalMoves возвращает ходы, после которых свой король не под шахом
+func (p *chessPosition) legalMoves() []chessMove {
+	var legal []chessMove
+	for _, move := range p.pseudoMoves() {
+		next := p.play(move)
+		if king := next.kingSquare(p.white); king >= 0 && !next.attacked(king, next.white) {
+			legal = append(legal, move)
+		}
+	}
+	return legal
+}
+
+// insufficientMaterial проверяет, что мат поставить невозможно: остались
+// только короли и, может быть, один слон или конь
+func (p *chessPosition) insufficientMaterial() bool {
+	minor := 0
+	for _, piece := range p.board {
+		switch lowerPiece(piece) {
+		case 0, 'k':
+		case 'b', 'n':
+			minor++
+		default:
+			return false
+		}
+	}
+	return minor <= 1
+}
+
+// uci записывает ход в нотации UCI
+func (m chessMove) uci() string {
+	text := squareName(m.from) + squareName(m.to)
+	if m.promotion != 0 {
+		text += string(m.promotion)
+	}
+	return text
+}
+
+// san записывает ход в алгебраической нотации. legal — все допустимые ходы позиции
+func (p *chessPosition) san(move chessMove, legal []chessMove) string {
+	var text string
+	piece := lowerPiece(p.board[move.from])
+
+	switch {
+	case move.castle && move.to > move.from:
+		text = "O-O"
+	case move.castle:
+		text = "O-O-O"
+	case piece == 'p':
+		if move.from%8 != move.to%8 {
+			text = squareName(move.from)[:1] + "x"
+		}
+		text += squareName(move.to)
+		if move.promotion != 0 {
+			text += "=" + strings.ToUpper(string(move.promotion))
+		}
+	default:
+		text = strings.ToUpper(string(piece))
+
+		// Уточняем вертикаль или горизонталь, если на поле может пойти такая же фигура
+		ambiguous, sameFile, sameRank := false, false, false
+		for _, other := range legal {
+			if other.to != move.to || other.from == move.from || lowerPiece(p.board[other.from]) != piece {
+				continue
+			}
+			ambiguous = true
+			sameFile = sameFile || other.from%8 == move.from%8
+			sameRank = sameRank || other.from/8 == move.from/8
+		}
+		from := squareName(move.from)
+		switch {
+		case !ambiguous:
+		case !sameFile:
+			text += from[:1]
+		case !sameRank:
+			text += from[1:]
+		default:
+			text += from
+		}
+
+		if p.board[move.to] != 0 {
+			text += "x"
+		}
+		text += squareName(move.to)
+	}
+
+	next := p.play(move)
+	if next.inCheck() {
+		if len(next.legalMoves()) == 0 {
+			return text + "#"
+		}
+		return text + "+"
+	}
+	return text
+}
+
+var uciPattern = regexp.MustCompile(`^[a-h][1-8][a-h][1-8][qrbn]?$`)
+
+// parseMove находит среди допустимых ходов ход, записанный в UCI или SAN
+func (p *chessPosition) parseMove(text string, legal []chessMove) (chessMove, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return chessMove{}, fmt.Errorf("пустой ход")
+	}
+
+	if uciPattern.MatchString(text) {
+		for _, move := range legal {
+			if move.uci() == text {
+				return move, nil
+			}
+		}
+		return chessMove{}, fmt.Errorf("недопустимый ход %s", text)
+	}
+
+	wanted := normalizeSAN(text)
+	for _, move := range legal {
+		if normalizeSAN(p.san(move, legal)) == wanted {
+			return move, nil
+		}
+	}
+	return chessMove{}, fmt.Errorf("недопустимый ход %s", text)
+}
+
+// normalizeSAN убирает из записи хода необязательные знаки: шах, оценки, "=" при превращении
+func normalizeSAN(text string) string {
+	text = strings.ReplaceAll(text, "0", "O")
+	text = strings.TrimSuffix(text, "e.p.")
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune("+#!?= ", r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// squareName имя поля ("e4")
+func squareName(square int) string {
+	return string([]byte{byte('a' + square%8), byte('1' + square/8)})
+}
+
+// parseSquare номер поля по имени, -1 — некорректное имя
+func parseSquare(name string) int {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return -1
+	}
+	return int(name[1]-'1')*8 + int(name[0]-'a')
+}
+
+func onChessBoard(file, rank int) bool {
+	return file >= 0 && file < 8 && rank >= 0 && rank < 8
+}
+
+func isWhitePiece(piece byte) bool {
+	return piece >= 'A' && piece <= 'Z'
+}
+
+func lowerPiece(piece byte) byte {
+	if isWhitePiece(piece) {
+		return piece - 'A' + 'a'
+	}
+	return piece
+}
+
+// pgn записывает завершенную шахматную партию в PGN
+func (gm *GameManager) pgn(gameID string) (string, error) {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
+
+	game, exists := gm.games[gameID]
+	if !exists {
+		return "", errGameNotFound
+	}
+	state, ok := game.State.(*ChessState)
+	if !ok {
+		return "", fmt.Errorf("это не шахматная партия")
+	}
+	if game.Status != "finished" && game.Status != "restart_requested" {
+		return "", fmt.Errorf("игра еще не завершена")
+	}
+
+	result := "1/2-1/2"
+	switch game.Winner {
+	case "white":
+		result = "1-0"
+	case "black":
+		result = "0-1"
+	}
+
+	names := []string{"?", "?"}
+	for i := range names {
+		if i < len(game.Players) {
+			names[i] = strings.ReplaceAll(game.Players[i].Name, `"`, `'`)
+		}
+	}
+
+	// Игра перезапускается, поэтому дата и номер берутся у текущей партии
+	started := game.Started
+	if started.IsZero() {
+		started = game.Created
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Event \"Игра %s\"]\n", game.ID)
+	fmt.Fprintf(&b, "[Site \"Telegram\"]\n")
+	fmt.Fprintf(&b, "[Date \"%s\"]\n", started.Format("2006.01.02"))
+	fmt.Fprintf(&b, "[Round \"%d\"]\n", max(game.Round, 1))
+	fmt.Fprintf(&b, "[White \"%s\"]\n", names[0])
+	fmt.Fprintf(&b, "[Black \"%s\"]\n", names[1])
+	fmt.Fprintf(&b, "[Result \"%s\"]\n", result)
+	fmt.Fprintf(&b, "[Termination \"%s\"]\n\n", state.Result)
+
+	// Текст партии переносится по 80 символов
+	var tokens []string
+	for i, move := range state.Moves {
+		if i%2 == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d.", i/2+1))
+		}
+		tokens = append(tokens, move.SAN)
+	}
+	tokens = append(tokens, result)
+
+	line := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if line+1+len(token) > 80 {
+				b.WriteByte('\n')
+				line = 0
+			} else {
+				b.WriteByte(' ')
+				line++
+			}
+		}
+		b.WriteString(token)
+		line += len(token)
+	}
+	b.WriteByte('\n')
+	return b.String(), nil
+}
+
+// pgnHandler отдает завершенную шахматную партию в PGN
+func pgnHandler(w http.ResponseWriter, r *http.Request) {
+	gameID := mux.Vars(r)["gameId"]
+
+	pgn, err := gameManager.pgn(gameID)
+	if errors.Is(err, errGameNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-chess-pgn; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", gameID+".pgn"))
+	if _, err := w.Write([]byte(pgn)); err != nil {
+		log.Printf("Ошибка отправки PGN игры %s: %v", gameID, err)
+	}
+}
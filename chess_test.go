@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// perft считает листья дерева допустимых ходов глубины depth
+func perft(p *chessPosition, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	moves := p.legalMoves()
+	if depth == 1 {
+		return len(moves)
+	}
+	nodes := 0
+	for _, move := range moves {
+		nodes += perft(p.play(move), depth-1)
+	}
+	return nodes
+}
+
+func mustParseFEN(t *testing.T, fen string) *chessPosition {
+	t.Helper()
+	p, err := parseFEN(fen)
+	if err != nil {
+		t.Fatalf("parseFEN(%q): %v", fen, err)
+	}
+	return p
+}
+
+// Эталонные значения: https://www.chessprogramming.org/Perft_Results
+func TestChessPerft(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		nodes []int // Число позиций на глубине 1, 2, ...
+	}{
+		{"начальная позиция", chessStartFEN, []int{20, 400, 8902, 197281}},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []int{48, 2039, 97862}},
+		{"позиция 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []int{14, 191, 2812, 43238}},
+		{"позиция 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []int{6, 264, 9467}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := mustParseFEN(t, tt.fen)
+			for i, want := range tt.nodes {
+				depth := i + 1
+				if testing.Short() && depth > 2 {
+					break
+				}
+				if got := perft(p, depth); got != want {
+					t.Errorf("perft(%d) = %d, ожидалось %d", depth, got, want)
+				}
+			}
+		})
+	}
+}
+
+// findMove находит допустимый ход по записи UCI
+func findMove(t *testing.T, p *chessPosition, uci string) (chessMove, []chessMove) {
+	t.Helper()
+	legal := p.legalMoves()
+	for _, move := range legal {
+		if move.uci() == uci {
+			return move, legal
+		}
+	}
+	t.Fatalf("ход %s недопустим", uci)
+	return chessMove{}, nil
+}
+
+func TestChessSAN(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		uci  string
+		san  string
+	}{
+		{"без уточнения", chessStartFEN, "g1f3", "Nf3"},
+		{"уточнение вертикалью", "4k3/8/8/8/8/8/4K3/R6R w - - 0 1", "a1d1", "Rad1"},
+		{"уточнение горизонталью", "4k3/8/8/R7/8/8/4K3/R7 w - - 0 1", "a1a3", "R1a3"},
+		{"уточнение полем", "7k/8/8/8/Q1Q5/8/Q7/7K w - - 0 1", "a4b3", "Qa4b3"},
+		{"короткая рокировка", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"длинная рокировка", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "O-O-O"},
+		{"взятие на проходе", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", "exd6"},
+		{"превращение", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8q", "a8=Q"},
+		{"слабое превращение", "8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8n", "a8=N"},
+		{"превращение со взятием и шахом", "1r5k/P7/8/8/8/8/8/K7 w - - 0 1", "a7b8q", "axb8=Q+"},
+		{"мат", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4", "Qh4#"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := mustParseFEN(t, tt.fen)
+			move, legal := findMove(t, p, tt.uci)
+			if got := p.san(move, legal); got != tt.san {
+				t.Errorf("san(%s) = %q, ожидалось %q", tt.uci, got, tt.san)
+			}
+
+			// Запись SAN читается обратно в тот же ход
+			parsed, err := p.parseMove(tt.san, legal)
+			if err != nil {
+				t.Fatalf("parseMove(%q): %v", tt.san, err)
+			}
+			if parsed.uci() != tt.uci {
+				t.Errorf("parseMove(%q) = %s, ожидалось %s", tt.san, parsed.uci(), tt.uci)
+			}
+		})
+	}
+}
+
+func TestChessParseMoveLenient(t *testing.T) {
+	tests := []struct {
+		fen  string
+		text string
+		uci  string
+	}{
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0", "e1g1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O-O+", "e1c1"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8Q", "a7a8q"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6 e.p.", "e5d6"},
+	}
+
+	for _, tt := range tests {
+		p := mustParseFEN(t, tt.fen)
+		move, err := p.parseMove(tt.text, p.legalMoves())
+		if err != nil {
+			t.Errorf("parseMove(%q): %v", tt.text, err)
+			continue
+		}
+		if move.uci() != tt.uci {
+			t.Errorf("parseMove(%q) = %s, ожидалось %s", tt.text, move.uci(), tt.uci)
+		}
+	}
+
+	// Конь с b1 и конь с f3 оба могут пойти на d2: без уточнения ход неоднозначен
+	p := mustParseFEN(t, "4k3/8/8/8/8/5N2/8/1N2K3 w - - 0 1")
+	if _, err := p.parseMove("Nd2", p.legalMoves()); err == nil {
+		t.Error("parseMove(\"Nd2\") принял неоднозначный ход")
+	}
+}
+
+func TestChessPlaySpecialMoves(t *testing.T) {
+	// Рокировка переносит ладью и снимает права на рокировку
+	p := mustParseFEN(t, "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	move, _ := findMove(t, p, "e1g1")
+	if got := p.play(move).key(); got != "r3k2r/8/8/8/8/8/8/R4RK1 b kq -" {
+		t.Errorf("после O-O: %q", got)
+	}
+
+	// Взятие на проходе убирает пешку с d5
+	p = mustParseFEN(t, "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1")
+	move, _ = findMove(t, p, "e5d6")
+	if got := p.play(move).key(); got != "4k3/8/3P4/8/8/8/8/4K3 b - -" {
+		t.Errorf("после exd6: %q", got)
+	}
+
+	// Превращение ставит выбранную фигуру
+	p = mustParseFEN(t, "8/P6k/8/8/8/8/8/K7 w - - 0 1")
+	move, _ = findMove(t, p, "a7a8n")
+	if got := p.play(move).key(); got != "N7/7k/8/8/8/8/8/K7 b - -" {
+		t.Errorf("после a8=N: %q", got)
+	}
+}
+
+func TestChessKeyEnPassant(t *testing.T) {
+	// Поле взятия на проходе входит в ключ, только если взять действительно можно
+	withoutCapture := mustParseFEN(t, "4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1")
+	if got := withoutCapture.key(); got != "4k3/8/8/8/4P3/8/8/4K3 b - -" {
+		t.Errorf("key() = %q, поле e3 не должно учитываться", got)
+	}
+
+	withCapture := mustParseFEN(t, "4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1")
+	if got := withCapture.key(); got != "4k3/8/8/8/3pP3/8/8/4K3 b - e3" {
+		t.Errorf("key() = %q, поле e3 должно учитываться", got)
+	}
+}
+
+// newChessGame создает начатую шахматную партию
+func newChessGame(t *testing.T) *Game {
+	t.Helper()
+	state, err := ChessEngine{}.NewState(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Game{
+		ID:      "CHESS1",
+		Type:    "chess",
+		State:   state,
+		Players: []Player{{ID: "a", Name: "Alice", Symbol: "white"}, {ID: "b", Name: "Bob", Symbol: "black"}},
+		Status:  "playing",
+	}
+}
+
+func TestChessThreefold(t *testing.T) {
+	game := newChessGame(t)
+	engine := ChessEngine{}
+	state := game.State.(*ChessState)
+
+	// Начальная позиция повторяется после каждых четырех ходов конями
+	moves := []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8"}
+	for i, move := range moves {
+		if err := engine.makeMove(game, game.Turn, move); err != nil {
+			t.Fatalf("ход %s: %v", move, err)
+		}
+		last := i == len(moves)-1
+		if (state.Result == "threefold") != last {
+			t.Fatalf("после хода %d (%s) результат %q", i+1, move, state.Result)
+		}
+	}
+
+	finished, winner := engine.Outcome(game)
+	if !finished || winner != "draw" {
+		t.Errorf("Outcome() = %v, %q, ожидалась ничья", finished, winner)
+	}
+}
+
+func TestChessPGN(t *testing.T) {
+	game := newChessGame(t)
+	state := game.State.(*ChessState)
+	for i := 0; i < 30; i++ {
+		state.Moves = append(state.Moves, ChessMoveRecord{SAN: []string{"Nf3", "Nf6", "Ng1", "Ng8"}[i%4]})
+	}
+	state.Result = "threefold"
+	game.Status = "finished"
+	game.Winner = "draw"
+	game.Created = time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	game.Started = time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	game.Round = 3
+
+	gm := &GameManager{games: map[string]*Game{game.ID: game}}
+	pgn, err := gm.pgn(game.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, movetext, found := strings.Cut(pgn, "\n\n")
+	if !found {
+		t.Fatalf("нет пустой строки после заголовков:\n%s", pgn)
+	}
+	for _, tag := range []string{`[Date "2026.03.04"]`, `[Round "3"]`, `[White "Alice"]`, `[Black "Bob"]`, `[Result "1/2-1/2"]`} {
+		if !strings.Contains(header, tag) {
+			t.Errorf("в заголовках нет %s:\n%s", tag, header)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(movetext, "\n"), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("текст партии не перенесен:\n%s", movetext)
+	}
+	for _, line := range lines {
+		if len(line) > 80 || strings.HasPrefix(line, " ") || strings.HasSuffix(line, " ") {
+			t.Errorf("некорректная строка PGN (%d символов): %q", len(line), line)
+		}
+	}
+
+	var tokens []string
+	for i, move := range state.Moves {
+		if i%2 == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d.", i/2+1))
+		}
+		tokens = append(tokens, move.SAN)
+	}
+	tokens = append(tokens, "1/2-1/2")
+	if got, want := strings.Join(lines, " "), strings.Join(tokens, " "); got != want {
+		t.Errorf("текст партии:\n%s\nожидалось:\n%s", got, want)
+	}
+}
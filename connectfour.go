@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	connectFourRows    = 6
+	connectFourColumns = 7
+)
+
+// ConnectFourState состояние игры «Четыре в ряд»
+type ConnectFourState struct {
+	Board    [][]string `json:"board"`              // Board[row][col], строка 0 — верхняя
+	LastMove *[2]int    `json:"lastMove,omitempty"` // Клетка последней фишки (row, col)
+}
+
+// DropData для хода в «Четыре в ряд»: фишка падает в столбец Column
+type DropData struct {
+	GameID   string `json:"gameId"`
+	Column   int    `json:"column"`
+	PlayerID string `json:"playerId"`
+}
+
+// ConnectFourEngine правила игры «Четыре в ряд»
+type ConnectFourEngine struct{}
+
+func init() {
+	registerEngine("connectfour", ConnectFourEngine{})
+}
+
+func (ConnectFourEngine) NewState(options json.RawMessage) (interface{}, error) {
+	board := make([][]string, connectFourRows)
+	for row := range board {
+		board[row] = make([]string, connectFourColumns)
+	}
+	return &ConnectFourState{Board: board}, nil
+}
+
+func (ConnectFourEngine) Symbol(playerIndex int) string {
+	if playerIndex == 0 {
+		return "R"
+	}
+	return "Y"
+}
+
+func (ConnectFourEngine) StartStatus() string {
+	return "playing"
+}
+
+func (e ConnectFourEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	if action != "drop" {
+		return fmt.Errorf("неизвестное действие")
+	}
+
+	var drop DropData
+	if err := json.Unmarshal(data, &drop); err != nil {
+		return fmt.Errorf("некорректный ход")
+	}
+	return e.drop(game, playerIndex, drop.Column)
+}
+
+// drop бросает фишку игрока в столбец: она падает на самую нижнюю свободную клетку
+func (ConnectFourEngine) drop(game *Game, playerIndex, column int) error {
+	state := game.State.(*ConnectFourState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	if column < 0 || column >= connectFourColumns {
+		return fmt.Errorf("неверный столбец")
+	}
+
+	row := len(state.Board) - 1
+	for row >= 0 && state.Board[row][column] != "" {
+		row--
+	}
+	if row < 0 {
+		return fmt.Errorf("столбец заполнен")
+	}
+
+	state.Board[row][column] = game.Players[playerIndex].Symbol
+	state.LastMove = &[2]int{row, column}
+
+	if checkWinnerTicTacToe(state.Board, 4, row, column) == "" && !isBoardFull(state.Board) {
+		game.Turn = 1 - game.Turn
+	}
+	return nil
+}
+
+func (ConnectFourEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*ConnectFourState)
+	if state.LastMove == nil {
+		return false, ""
+	}
+	if winner := checkWinnerTicTacToe(state.Board, 4, state.LastMove[0], state.LastMove[1]); winner != "" {
+		return true, winner
+	}
+	if isBoardFull(state.Board) {
+		return true, "draw"
+	}
+	return false, ""
+}
+
+func (ConnectFourEngine) View(game *Game, playerIndex int) interface{} {
+	state := *game.State.(*ConnectFourState)
+	state.Board = copyGrid(state.Board)
+	return &state
+}
+
+func (e ConnectFourEngine) Reset(game *Game) {
+	game.State, _ = e.NewState(game.Options)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DotsState состояние игры «Точки и квадраты» на поле Rows x Cols квадратов
+type DotsState struct {
+	Rows       int            `json:"rows"`
+	Cols       int            `json:"cols"`
+	Horizontal [][]string     `json:"horizontal"`         // Horizontal[row][col]: линия от точки (row, col) вправо; (Rows+1) x Cols
+	Vertical   [][]string     `json:"vertical"`           // Vertical[row][col]: линия от точки (row, col) вниз; Rows x (Cols+1)
+	Boxes      [][]string     `json:"boxes"`              // Boxes[row][col]: кто закрыл квадрат
+	LastLine   *DotsLine      `json:"lastLine,omitempty"` // Последняя линия
+	Closed     [][2]int       `json:"closed,omitempty"`   // Квадраты, закрытые последней линией
+	Score      map[string]int `json:"score,omitempty"`    // Закрытые квадраты игроков; только в представлении
+}
+
+// DotsLine линия между соседними точками: "h" — горизонтальная, "v" — вертикальная
+type DotsLine struct {
+	Orientation string `json:"orientation"`
+	Row         int    `json:"row"`
+	Col         int    `json:"col"`
+}
+
+// DotsOptions настройки поля
+type DotsOptions struct {
+	Rows int `json:"rows"` // По умолчанию 4
+	Cols int `json:"cols"` // По умолчанию Rows
+}
+
+// LineData для хода в «Точки и квадраты»
+type LineData struct {
+	GameID string `json:"gameId"`
+	DotsLine
+	PlayerID string `json:"playerId"`
+}
+
+const (
+	minDotsSize = 2
+	maxDotsSize = 10
+)
+
+// DotsEngine правила игры «Точки и квадраты»
+type DotsEngine struct{}
+
+func init() {
+	registerEngine("dots", DotsEngine{})
+}
+
+func (DotsEngine) NewState(options json.RawMessage) (interface{}, error) {
+	var opts DotsOptions
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &opts); err != nil {
+			return nil, fmt.Errorf("некорректные настройки игры")
+		}
+	}
+
+	if opts.Rows == 0 {
+		opts.Rows = 4
+	}
+	if opts.Cols == 0 {
+		opts.Cols = opts.Rows
+	}
+	for _, size := range []int{opts.Rows, opts.Cols} {
+		if size < minDotsSize || size > maxDotsSize {
+			return nil, fmt.Errorf("размер поля должен быть от %d до %d квадратов", minDotsSize, maxDotsSize)
+		}
+	}
+
+	return &DotsState{
+		Rows:       opts.Rows,
+		Cols:       opts.Cols,
+		Horizontal: newDotsGrid(opts.Rows+1, opts.Cols),
+		Vertical:   newDotsGrid(opts.Rows, opts.Cols+1),
+		Boxes:      newDotsGrid(opts.Rows, opts.Cols),
+	}, nil
+}
+
+func (DotsEngine) Symbol(playerIndex int) string {
+	if playerIndex == 0 {
+		return "R"
+	}
+	return "B"
+}
+
+func (DotsEngine) StartStatus() string {
+	return "playing"
+}
+
+func (e DotsEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	if action != "line" {
+		return fmt.Errorf("неизвестное действие")
+	}
+
+	var line LineData
+	if err := json.Unmarshal(data, &line); err != nil {
+		return fmt.Errorf("некорректный ход")
+	}
+	return e.drawLine(game, playerIndex, line.DotsLine)
+}
+
+// drawLine проводит линию. Если она закрыла квадрат, игрок ходит еще раз
+func (DotsEngine) drawLine(game *Game, playerIndex int, line DotsLine) error {
+	state := game.State.(*DotsState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	var lines [][]string
+	switch line.Orientation {
+	case "h":
+		lines = state.Horizontal
+	case "v":
+		lines = state.Vertical
+	default:
+		return fmt.Errorf("неверное направление линии")
+	}
+	if line.Row < 0 || line.Row >= len(lines) || line.Col < 0 || line.Col >= len(lines[line.Row]) {
+		return fmt.Errorf("неверная позиция")
+	}
+	if lines[line.Row][line.Col] != "" {
+		return fmt.Errorf("линия уже проведена")
+	}
+
+	symbol := game.Players[playerIndex].Symbol
+	lines[line.Row][line.Col] = symbol
+	state.LastLine = &line
+
+	// Горизонтальная линия граничит с квадратами сверху и снизу, вертикальная — слева и справа
+	candidates := [][2]int{{line.Row - 1, line.Col}, {line.Row, line.Col}}
+	if line.Orientation == "v" {
+		candidates = [][2]int{{line.Row, line.Col - 1}, {line.Row, line.Col}}
+	}
+
+	state.Closed = nil
+	for _, box := range candidates {
+		row, col := box[0], box[1]
+		if row < 0 || row >= state.Rows || col < 0 || col >= state.Cols {
+			continue
+		}
+		if state.Horizontal[row][col] != "" && state.Horizontal[row+1][col] != "" &&
+			state.Vertical[row][col] != "" && state.Vertical[row][col+1] != "" {
+			state.Boxes[row][col] = symbol
+			state.Closed = append(state.Closed, box)
+		}
+	}
+
+	if len(state.Closed) == 0 {
+		game.Turn = 1 - game.Turn
+	}
+	return nil
+}
+
+// Outcome: когда все квадраты закрыты, побеждает тот, у кого их больше
+func (e DotsEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*DotsState)
+	if !isBoardFull(state.Boxes) {
+		return false, ""
+	}
+
+	score := dotsScore(state.Boxes)
+	switch {
+	case score[e.Symbol(0)] > score[e.Symbol(1)]:
+		return true, e.Symbol(0)
+	case score[e.Symbol(1)] > score[e.Symbol(0)]:
+		return true, e.Symbol(1)
+	}
+	return true, "draw"
+}
+
+func (DotsEngine) View(game *Game, playerIndex int) interface{} {
+	state := *game.State.(*DotsState)
+	state.Horizontal = copyGrid(state.Horizontal)
+	state.Vertical = copyGrid(state.Vertical)
+	state.Boxes = copyGrid(state.Boxes)
+	state.Closed = append([][2]int{}, state.Closed...)
+	state.Score = dotsScore(state.Boxes)
+	return &state
+}
+
+func (e DotsEngine) Reset(game *Game) {
+	// Настройки проверены при создании игры
+	game.State, _ = e.NewState(game.Options)
+}
+
+// newDotsGrid создает пустую сетку rows x cols
+func newDotsGrid(rows, cols int) [][]string {
+	grid := make([][]string, rows)
+	for row := range grid {
+		grid[row] = make([]string, cols)
+	}
+	return grid
+}
+
+// dotsScore считает закрытые квадраты каждого игрока
+func dotsScore(boxes [][]string) map[string]int {
+	score := map[string]int{"R": 0, "B": 0}
+	for _, row := range boxes {
+		for _, owner := range row {
+			if owner != "" {
+				score[owner]++
+			}
+		}
+	}
+	return score
+}
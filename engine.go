@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// GameEngine описывает правила одного типа игры. GameManager ничего не знает
+// о конкретных играх и работает с состоянием только через движок
+type GameEngine interface {
+	// NewState создает состояние новой игры. options — настройки партии из
+	// запроса на создание (nil — настройки по умолчанию)
+	NewState(options json.RawMessage) (interface{}, error)
+	// Symbol возвращает символ игрока с индексом playerIndex
+	Symbol(playerIndex int) string
+	// StartStatus возвращает статус, в который игра переходит, когда все в сборе
+	StartStatus() string
+	// Apply проверяет и применяет действие игрока
+	Apply(game *Game, playerIndex int, action string, data json.RawMessage) error
+	// Outcome сообщает, окончена ли игра и кто победил
+	Outcome(game *Game) (finished bool, winner string)
+	// View возвращает ту часть состояния, которую может видеть игрок (-1 — не участник)
+	View(game *Game, playerIndex int) interface{}
+	// Reset возвращает состояние к началу новой партии
+	Reset(game *Game)
+}
+
+// OptionsValidator — необязательный интерфейс движка: проверки настроек,
+// присланных клиентом, которые нужны один раз при создании игры или
+// постановке в очередь, а не при каждом NewState (перезапуск, загрузка, повтор)
+type OptionsValidator interface {
+	ValidateOptions(options json.RawMessage) error
+}
+
+// validateOptions проверяет настройки новой игры: NewState и, если движок их
+// поддерживает, дополнительные проверки ValidateOptions
+func validateOptions(engine GameEngine, options json.RawMessage) (interface{}, error) {
+	state, err := engine.NewState(options)
+	if err != nil {
+		return nil, err
+	}
+	if validator, ok := engine.(OptionsValidator); ok {
+		if err := validator.ValidateOptions(options); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// ActionNormalizer — необязательный интерфейс движка: переписывает данные
+// действия перед применением и записью в журнал. Нужен, когда действие
+// содержит случайность — в журнал попадает уже выбранное зерно, и повтор
+// партии остается детерминированным
+type ActionNormalizer interface {
+	NormalizeAction(game *Game, playerIndex int, action string, data json.RawMessage) (json.RawMessage, error)
+}
+
+// engines — реестр движков по типу игры
+var engines = map[string]GameEngine{}
+
+// registerEngine регистрирует движок игры. Вызывается из init() файла игры
+func registerEngine(gameType string, engine GameEngine) {
+	if _, exists := engines[gameType]; exists {
+		panic(fmt.Sprintf("движок %s уже зарегистрирован", gameType))
+	}
+	engines[gameType] = engine
+}
+
+// engineFor возвращает движок для типа игры
+func engineFor(gameType string) (GameEngine, bool) {
+	engine, exists := engines[gameType]
+	return engine, exists
+}
+
+// checkTurn проверяет, что игра идет и сейчас ход игрока
+func checkTurn(game *Game, playerIndex int) error {
+	if game.Status != "playing" {
+		return fmt.Errorf("игра не активна")
+	}
+	if game.Turn != playerIndex {
+		return fmt.Errorf("не ваш ход")
+	}
+	return nil
+}
+
+// newGrid создает пустую сетку size x size
+func newGrid(size int) [][]string {
+	grid := make([][]string, size)
+	for y := range grid {
+		grid[y] = make([]string, size)
+	}
+	return grid
+}
+
+// copyGrid возвращает независимую копию сетки
+func copyGrid(grid [][]string) [][]string {
+	copied := make([][]string, len(grid))
+	for y := range grid {
+		copied[y] = append([]string{}, grid[y]...)
+	}
+	return copied
+}
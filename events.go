@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// GameEvent — принятое сервером действие игрока. Состояние игры можно
+// восстановить, применив события по порядку к начальному состоянию
+type GameEvent struct {
+	Seq    int             `json:"seq"`    // Порядковый номер, начиная с 1
+	Time   time.Time       `json:"time"`   // Когда действие было принято
+	Player int             `json:"player"` // Индекс игрока
+	Action string          `json:"action"` // Тип действия ("move", "attack", ...)
+	Data   json.RawMessage `json:"data"`   // Данные действия в том виде, в каком их принял движок
+}
+
+// ReplayStep состояние игры после события с номером Seq
+type ReplayStep struct {
+	Seq    int             `json:"seq"`
+	State  json.RawMessage `json:"state"`
+	Turn   int             `json:"turn"`
+	Status string          `json:"status"`
+	Winner string          `json:"winner"`
+}
+
+// Replay запись партии для пошагового просмотра
+type Replay struct {
+	GameID  string          `json:"gameId"`
+	Type    string          `json:"type"`
+	Options json.RawMessage `json:"options,omitempty"`
+	Players []Player        `json:"players"`
+	Initial json.RawMessage `json:"initial"` // Состояние до первого хода
+	Events  []GameEvent     `json:"events"`
+	Steps   []ReplayStep    `json:"steps"` // Steps[i] — состояние после Events[i]
+	Winner  string          `json:"winner"`
+}
+
+// recordEvent добавляет принятое действие в журнал игры
+func (g *Game) recordEvent(playerIndex int, action string, data json.RawMessage) {
+	g.Events = append(g.Events, GameEvent{
+		Seq:    len(g.Events) + 1,
+		Time:   time.Now(),
+		Player: playerIndex,
+		Action: action,
+		Data:   data,
+	})
+}
+
+// logf пишет в лог событие партии. При повторе партии из журнала молчит:
+// события уже произошли и были записаны в лог тогда
+func (g *Game) logf(format string, args ...interface{}) {
+	if g.replaying {
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// replayGame заново проигрывает журнал событий игры gameID с начального
+// состояния и возвращает состояние после каждого шага
+func replayGame(gameID, gameType string, options json.RawMessage, players []Player, events []GameEvent) (*Replay, error) {
+	engine, exists := engineFor(gameType)
+	if !exists {
+		return nil, fmt.Errorf("неверный тип игры")
+	}
+
+	state, err := engine.NewState(options)
+	if err != nil {
+		return nil, err
+	}
+
+	game := &Game{
+		ID:        gameID,
+		Type:      gameType,
+		Options:   options,
+		State:     state,
+		Players:   players,
+		Status:    engine.StartStatus(),
+		replaying: true,
+	}
+
+	initial, err := json.Marshal(game.State)
+	if err != nil {
+		return nil, err
+	}
+
+	replay := &Replay{
+		GameID:  gameID,
+		Type:    gameType,
+		Options: options,
+		Players: players,
+		Initial: initial,
+		Events:  events,
+		Steps:   make([]ReplayStep, 0, len(events)),
+	}
+
+	for _, event := range events {
+		if err := engine.Apply(game, event.Player, event.Action, event.Data); err != nil {
+			return nil, fmt.Errorf("событие %d: %w", event.Seq, err)
+		}
+		if finished, winner := engine.Outcome(game); finished {
+			game.Status = "finished"
+			game.Winner = winner
+		}
+
+		state, err := json.Marshal(game.State)
+		if err != nil {
+			return nil, err
+		}
+		replay.Steps = append(replay.Steps, ReplayStep{
+			Seq:    event.Seq,
+			State:  state,
+			Turn:   game.Turn,
+			Status: game.Status,
+			Winner: game.Winner,
+		})
+	}
+
+	replay.Winner = game.Winner
+	return replay, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestReplayGameIsQuiet(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	players := []Player{{ID: "a", Name: "Alice"}, {ID: "b", Name: "Bob"}}
+	events := []GameEvent{
+		{Seq: 1, Player: 0, Action: "placeShips", Data: json.RawMessage(`{"random":true,"seed":1}`)},
+		{Seq: 2, Player: 1, Action: "placeShips", Data: json.RawMessage(`{"random":true,"seed":2}`)},
+	}
+
+	replay, err := replayGame("SEA001", "battleship", nil, players, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.GameID != "SEA001" {
+		t.Errorf("GameID = %q, ожидалось SEA001", replay.GameID)
+	}
+	if replay.Steps[1].Status != "playing" {
+		t.Errorf("после расстановки обоих флотов статус %q", replay.Steps[1].Status)
+	}
+	if output.Len() > 0 {
+		t.Errorf("повтор партии пишет в лог:\n%s", output.String())
+	}
+}
@@ -5,4 +5,7 @@ go 1.23.2
 require (
 	github.com/gorilla/mux v1.8.1
 	github.com/gorilla/websocket v1.5.3
+	go.etcd.io/bbolt v1.4.0
 )
+
+require golang.org/x/sys v0.29.0 // indirect
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// MatchRecord завершенная партия в архиве. Игры удаляются из памяти через
+// два часа, а архив хранит партии бессрочно вместе с журналом для повтора
+type MatchRecord struct {
+	ID       string          `json:"id"` // "<ID игры>-<номер партии>"
+	GameID   string          `json:"gameId"`
+	Type     string          `json:"type"`
+	Options  json.RawMessage `json:"options,omitempty"`
+	Players  []Player        `json:"players"`
+	Winner   string          `json:"winner"`
+	Started  time.Time       `json:"started"`
+	Finished time.Time       `json:"finished"`
+	Events   []GameEvent     `json:"events"`
+}
+
+// MatchHistoryEntry партия в истории игрока
+type MatchHistoryEntry struct {
+	MatchID  string    `json:"matchId"`
+	GameID   string    `json:"gameId"`
+	GameType string    `json:"gameType"`
+	Opponent Player    `json:"opponent"`
+	Result   string    `json:"result"` // "win", "loss" или "draw"
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Duration int       `json:"duration"` // Длительность в секундах
+	Moves    int       `json:"moves"`
+	Replay   string    `json:"replay"` // Ссылка на пошаговый просмотр
+}
+
+// MatchStore сохраняет архив партий между перезапусками сервера
+type MatchStore interface {
+	SaveMatch(match *MatchRecord) error
+	LoadMatches() ([]*MatchRecord, error)
+}
+
+// MatchArchive архив завершенных партий с индексом по игрокам
+type MatchArchive struct {
+	matches  map[string]*MatchRecord
+	byPlayer map[string][]*MatchRecord // Партии игрока в порядке завершения
+	store    MatchStore                // Постоянное хранилище, nil — только в памяти
+	mutex    sync.RWMutex
+}
+
+var matchArchive = &MatchArchive{
+	matches:  make(map[string]*MatchRecord),
+	byPlayer: make(map[string][]*MatchRecord),
+}
+
+// load загружает архив при запуске сервера
+func (a *MatchArchive) load() error {
+	if a.store == nil {
+		return nil
+	}
+
+	matches, err := a.store.LoadMatches()
+	if err != nil {
+		return err
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Finished.Before(matches[j].Finished)
+	})
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	for _, match := range matches {
+		a.addLocked(match)
+	}
+	log.Printf("Загружено партий из архива: %d", len(matches))
+	return nil
+}
+
+// addLocked добавляет партию в архив и индекс игроков. Вызывается под блокировкой
+func (a *MatchArchive) addLocked(match *MatchRecord) {
+	a.matches[match.ID] = match
+	for _, player := range match.Players {
+		a.byPlayer[player.ID] = append(a.byPlayer[player.ID], match)
+	}
+}
+
+// record архивирует только что завершенную партию. Вызывается под блокировкой игры
+func (a *MatchArchive) record(game *Game, now time.Time) {
+	started := game.Started
+	if started.IsZero() {
+		started = game.Created
+	}
+
+	players := make([]Player, len(game.Players))
+	for i, player := range game.Players {
+		players[i] = Player{ID: player.ID, Name: player.Name, Symbol: player.Symbol, Bot: player.Bot}
+	}
+
+	match := &MatchRecord{
+		ID:       fmt.Sprintf("%s-%d", game.ID, max(game.Round, 1)),
+		GameID:   game.ID,
+		Type:     game.Type,
+		Options:  game.Options,
+		Players:  players,
+		Winner:   game.Winner,
+		Started:  started,
+		Finished: now,
+		Events:   append([]GameEvent{}, game.Events...),
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	// ID игр повторяются после их удаления, архивные партии не перезаписываются
+	for base, i := match.ID, 2; a.matches[match.ID] != nil; i++ {
+		match.ID = fmt.Sprintf("%s.%d", base, i)
+	}
+	a.addLocked(match)
+
+	if a.store != nil {
+		if err := a.store.SaveMatch(match); err != nil {
+			log.Printf("Ошибка сохранения партии %s в архив: %v", match.ID, err)
+		}
+	}
+}
+
+// history возвращает страницу истории игрока, начиная с последних партий,
+// и общее число партий. gameType, если задан, оставляет партии одного типа
+func (a *MatchArchive) history(playerID, gameType string, page, pageSize int) ([]MatchHistoryEntry, int) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	matches := a.byPlayer[playerID]
+	if gameType != "" {
+		var filtered []*MatchRecord
+		for _, match := range matches {
+			if match.Type == gameType {
+				filtered = append(filtered, match)
+			}
+		}
+		matches = filtered
+	}
+
+	total := len(matches)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+
+	entries := make([]MatchHistoryEntry, 0, end-start)
+	for i := start; i < end; i++ {
+		entries = append(entries, historyEntry(matches[total-1-i], playerID))
+	}
+	return entries, total
+}
+
+// historyEntry описывает партию с точки зрения игрока playerID
+func historyEntry(match *MatchRecord, playerID string) MatchHistoryEntry {
+	game := &Game{Players: match.Players, Winner: match.Winner}
+	index := game.playerIndex(playerID)
+
+	entry := MatchHistoryEntry{
+		MatchID:  match.ID,
+		GameID:   match.GameID,
+		GameType: match.Type,
+		Result:   "draw",
+		Started:  match.Started,
+		Finished: match.Finished,
+		Duration: int(match.Finished.Sub(match.Started).Seconds()),
+		Moves:    len(match.Events),
+		Replay:   "/api/matches/" + match.ID + "/replay",
+	}
+	if len(match.Players) == 2 && index >= 0 {
+		entry.Opponent = match.Players[1-index]
+	}
+	switch game.winnerIndex() {
+	case -1:
+	case index:
+		entry.Result = "win"
+	default:
+		entry.Result = "loss"
+	}
+	return entry
+}
+
+// replay проигрывает журнал архивной партии
+func (a *MatchArchive) replay(matchID string) (*Replay, error) {
+	a.mutex.RLock()
+	match, exists := a.matches[matchID]
+	a.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("партия не найдена")
+	}
+
+	return replayGame(match.GameID, match.Type, match.Options, match.Players, match.Events)
+}
+
+// historyHandler отдает историю партий игрока: ?page=1&pageSize=20&gameType=chess
+func historyHandler(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["id"]
+	query := r.URL.Query()
+
+	gameType := query.Get("gameType")
+	if gameType != "" {
+		if _, exists := engineFor(gameType); !exists {
+			http.Error(w, "Неверный тип игры", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, pageSize, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, total := matchArchive.history(playerID, gameType, page, pageSize)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"playerId": playerID,
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+		"games":    entries,
+	})
+}
+
+// matchReplayHandler отдает пошаговый просмотр архивной партии
+func matchReplayHandler(w http.ResponseWriter, r *http.Request) {
+	matchID := mux.Vars(r)["matchId"]
+
+	replay, err := matchArchive.replay(matchID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(replay)
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Размер страницы в списках API
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// leaderboardWindows периоды таблиц: текущая неделя (ISO), текущий месяц и все время
+var leaderboardWindows = []string{"week", "month", "all"}
+
+// LeaderboardEntry строка таблицы лидеров
+type LeaderboardEntry struct {
+	Rank     int     `json:"rank"`
+	PlayerID string  `json:"playerId"`
+	Name     string  `json:"name"`
+	Wins     int     `json:"wins"`
+	Losses   int     `json:"losses"`
+	Draws    int     `json:"draws"`
+	WinRate  float64 `json:"winRate"` // Доля побед среди сыгранных партий
+	Rating   float64 `json:"rating"`  // Текущий рейтинг в типе игры
+}
+
+// LeaderboardRecord строка таблицы в хранилище
+type LeaderboardRecord struct {
+	GameType string           `json:"gameType"`
+	Period   string           `json:"period"`
+	Entry    LeaderboardEntry `json:"entry"`
+}
+
+// LeaderboardStore сохраняет таблицы лидеров между перезапусками сервера
+type LeaderboardStore interface {
+	SaveLeaderboardRecord(record *LeaderboardRecord) error
+	DeleteLeaderboardPeriod(gameType, period string) error
+	LoadLeaderboardRecords() ([]*LeaderboardRecord, error)
+}
+
+// leaderboard таблица одного типа игры за один период. Строки хранятся
+// отсортированными: запрос страницы не пересчитывает таблицу
+type leaderboard struct {
+	entries map[string]*LeaderboardEntry // Ключ — ID игрока
+	ranked  []*LeaderboardEntry
+}
+
+// Leaderboards таблицы лидеров, обновляемые после каждой рейтинговой партии
+type Leaderboards struct {
+	boards map[string]*leaderboard // Ключ — leaderboardKey
+	store  LeaderboardStore        // Постоянное хранилище, nil — только в памяти
+	mutex  sync.RWMutex
+}
+
+var leaderboards = &Leaderboards{
+	boards: make(map[string]*leaderboard),
+}
+
+func leaderboardKey(gameType, period string) string {
+	return gameType + "|" + period
+}
+
+// leaderboardPeriod возвращает период окна window, в который попадает момент t:
+// "2026-W42", "2026-10" или "all"
+func leaderboardPeriod(window string, t time.Time) string {
+	switch window {
+	case "week":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "month":
+		return t.Format("2006-01")
+	}
+	return "all"
+}
+
+// ranksBefore задает порядок таблицы: по рейтингу, затем по числу побед
+func ranksBefore(a, b *LeaderboardEntry) bool {
+	if a.Rating != b.Rating {
+		return a.Rating > b.Rating
+	}
+	if a.Wins != b.Wins {
+		return a.Wins > b.Wins
+	}
+	return a.PlayerID < b.PlayerID
+}
+
+// remove убирает строку из отсортированного списка
+func (l *leaderboard) remove(entry *LeaderboardEntry) {
+	i := sort.Search(len(l.ranked), func(i int) bool { return !ranksBefore(l.ranked[i], entry) })
+	if i < len(l.ranked) && l.ranked[i] == entry {
+		l.ranked = append(l.ranked[:i], l.ranked[i+1:]...)
+	}
+}
+
+// insert вставляет строку на ее место в отсортированном списке
+func (l *leaderboard) insert(entry *LeaderboardEntry) {
+	i := sort.Search(len(l.ranked), func(i int) bool { return !ranksBefore(l.ranked[i], entry) })
+	l.ranked = append(l.ranked, nil)
+	copy(l.ranked[i+1:], l.ranked[i:])
+	l.ranked[i] = entry
+}
+
+// board возвращает таблицу, создавая пустую. Вызывается под блокировкой
+func (lb *Leaderboards) board(gameType, period string) *leaderboard {
+	key := leaderboardKey(gameType, period)
+	board, exists := lb.boards[key]
+	if !exists {
+		board = &leaderboard{entries: make(map[string]*LeaderboardEntry)}
+		lb.boards[key] = board
+	}
+	return board
+}
+
+// load загружает сохраненные таблицы при запуске сервера
+func (lb *Leaderboards) load() error {
+	if lb.store == nil {
+		return nil
+	}
+
+	records, err := lb.store.LoadLeaderboardRecords()
+	if err != nil {
+		return err
+	}
+
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	for _, record := range records {
+		board := lb.board(record.GameType, record.Period)
+		entry := record.Entry
+		board.entries[entry.PlayerID] = &entry
+		board.ranked = append(board.ranked, &entry)
+	}
+	for _, board := range lb.boards {
+		sort.Slice(board.ranked, func(i, j int) bool { return ranksBefore(board.ranked[i], board.ranked[j]) })
+	}
+	log.Printf("Загружено строк таблиц лидеров: %d", len(records))
+	return nil
+}
+
+// record учитывает результат рейтинговой партии во всех окнах. scores —
+// очки игроков (1, 0.5 или 0), ratings — рейтинги после партии
+func (lb *Leaderboards) record(game *Game, playerIDs [2]string, scores [2]float64, ratings [2]float64, now time.Time) {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	for _, window := range leaderboardWindows {
+		period := leaderboardPeriod(window, now)
+		board := lb.board(game.Type, period)
+
+		for i, playerID := range playerIDs {
+			entry, exists := board.entries[playerID]
+			if exists {
+				board.remove(entry)
+			} else {
+				entry = &LeaderboardEntry{PlayerID: playerID}
+				board.entries[playerID] = entry
+			}
+
+			entry.Name = game.Players[i].Name
+			switch scores[i] {
+			case 1:
+				entry.Wins++
+			case 0:
+				entry.Losses++
+			default:
+				entry.Draws++
+			}
+			entry.WinRate = float64(entry.Wins) / float64(entry.Wins+entry.Losses+entry.Draws)
+			entry.Rating = ratings[i]
+			board.insert(entry)
+
+			if lb.store != nil {
+				record := &LeaderboardRecord{GameType: game.Type, Period: period, Entry: *entry}
+				if err := lb.store.SaveLeaderboardRecord(record); err != nil {
+					log.Printf("Ошибка сохранения таблицы лидеров: %v", err)
+				}
+			}
+		}
+	}
+
+	lb.dropStaleLocked(game.Type, now)
+}
+
+// dropStaleLocked удаляет таблицы прошедших недель и месяцев. Вызывается под блокировкой
+func (lb *Leaderboards) dropStaleLocked(gameType string, now time.Time) {
+	current := map[string]bool{}
+	for _, window := range leaderboardWindows {
+		current[leaderboardKey(gameType, leaderboardPeriod(window, now))] = true
+	}
+
+	for key := range lb.boards {
+		gameTypeOfKey, period, _ := strings.Cut(key, "|")
+		if gameTypeOfKey != gameType || current[key] {
+			continue
+		}
+		delete(lb.boards, key)
+		if lb.store != nil {
+			if err := lb.store.DeleteLeaderboardPeriod(gameType, period); err != nil {
+				log.Printf("Ошибка удаления таблицы лидеров %s: %v", key, err)
+			}
+		}
+	}
+}
+
+// page возвращает страницу таблицы и общее число строк
+func (lb *Leaderboards) page(gameType, window string, page, pageSize int, now time.Time) ([]LeaderboardEntry, int) {
+	lb.mutex.RLock()
+	defer lb.mutex.RUnlock()
+
+	board, exists := lb.boards[leaderboardKey(gameType, leaderboardPeriod(window, now))]
+	if !exists {
+		return []LeaderboardEntry{}, 0
+	}
+
+	start := min((page-1)*pageSize, len(board.ranked))
+	end := min(start+pageSize, len(board.ranked))
+	entries := make([]LeaderboardEntry, 0, end-start)
+	for i, entry := range board.ranked[start:end] {
+		row := *entry
+		row.Rank = start + i + 1
+		entries = append(entries, row)
+	}
+	return entries, len(board.ranked)
+}
+
+// parsePage читает параметры страницы ?page=&pageSize= с значениями по умолчанию
+func parsePage(query url.Values) (int, int, error) {
+	page, pageSize := 1, defaultPageSize
+	if value := query.Get("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, fmt.Errorf("неверный номер страницы")
+		}
+		page = parsed
+	}
+	if value := query.Get("pageSize"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPageSize {
+			return 0, 0, fmt.Errorf("размер страницы должен быть от 1 до %d", maxPageSize)
+		}
+		pageSize = parsed
+	}
+	return page, pageSize, nil
+}
+
+// leaderboardHandler отдает страницу таблицы лидеров:
+// ?gameType=chess&window=week|month|all&page=1&pageSize=20
+func leaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	gameType := query.Get("gameType")
+	if gameType == "" {
+		gameType = "tictactoe"
+	}
+	if _, exists := engineFor(gameType); !exists {
+		http.Error(w, "Неверный тип игры", http.StatusBadRequest)
+		return
+	}
+
+	window := query.Get("window")
+	if window == "" {
+		window = "all"
+	}
+	if window != "week" && window != "month" && window != "all" {
+		http.Error(w, "Период должен быть week, month или all", http.StatusBadRequest)
+		return
+	}
+
+	page, pageSize, err := parsePage(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	entries, total := leaderboards.page(gameType, window, page, pageSize, now)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"gameType": gameType,
+		"window":   window,
+		"period":   leaderboardPeriod(window, now),
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+		"players":  entries,
+	})
+}
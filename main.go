@@ -2,11 +2,13 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,46 +18,43 @@ import (
 
 // Game представляет игру
 type Game struct {
-	ID           string    `json:"id"`
-	Type         string    `json:"type"`   // "tictactoe" или "battleship"
-	Board        [9]string `json:"board"`  // Для крестиков-ноликов
-	Boards       []Board   `json:"boards"` // Для морского боя
-	Players      []Player  `json:"players"`
-	Turn         int       `json:"turn"`   // 0 или 1 - чей ход
-	Status       string    `json:"status"` // "waiting", "playing", "finished", "restart_requested"
-	Winner       string    `json:"winner"` // "", "X", "O", "draw", "player1", "player2"
-	Created      time.Time `json:"created"`
-	RestartVotes []string  `json:"restartVotes"` // ID игроков, проголосовавших за повтор
-}
-
-// Board для морского боя (10x10)
-type Board struct {
-	Grid  [10][10]string `json:"grid"`  // Сетка игрока
-	Ships []Ship         `json:"ships"` // Корабли игрока
-	Ready bool           `json:"ready"` // Готов ли игрок
-}
-
-// Ship представляет корабль в морском бое
-type Ship struct {
-	X         int    `json:"x"`
-	Y         int    `json:"y"`
-	Length    int    `json:"length"`
-	Direction string `json:"direction"` // "horizontal" или "vertical"
-	Hits      int    `json:"hits"`
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`              // Тип игры — ключ в реестре движков
+	Options      json.RawMessage `json:"options,omitempty"` // Настройки партии, переданные при создании
+	State        interface{}     `json:"state"`             // Состояние, которым управляет движок игры
+	Players      []Player        `json:"players"`
+	Turn         int             `json:"turn"`   // 0 или 1 - чей ход
+	Status       string          `json:"status"` // "waiting", "playing", "finished", "restart_requested"
+	Winner       string          `json:"winner"` // "", "X", "O", "draw", "player1", "player2"
+	Created      time.Time       `json:"created"`
+	Started      time.Time       `json:"started"`          // Начало текущей партии
+	Round        int             `json:"round"`            // Номер партии в игре, начиная с 1
+	Series       *Series         `json:"series,omitempty"` // Счет серии, если игра создана как серия партий
+	RestartVotes []string        `json:"restartVotes"`     // ID игроков, проголосовавших за повтор
+	Events       []GameEvent     `json:"events,omitempty"` // Журнал действий текущей партии
+
+	replaying bool // Партия проигрывается из журнала: движки не пишут в лог
 }
 
 // Player представляет игрока
 type Player struct {
-	ID     string          `json:"id"`
-	Name   string          `json:"name"`
-	Symbol string          `json:"symbol"` // "X" или "O" для крестиков-ноликов
-	Conn   *websocket.Conn `json:"-"`
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Symbol string  `json:"symbol"`        // "X" или "O" для крестиков-ноликов
+	Bot    string  `json:"bot,omitempty"` // Уровень сложности, если это бот
+	Client *Client `json:"-"`
 }
 
+// errGameNotFound игры нет в памяти: ее не создавали или она уже удалена
+var errGameNotFound = errors.New("игра не найдена")
+
 // GameManager управляет всеми играми
 type GameManager struct {
-	games map[string]*Game
-	mutex sync.RWMutex
+	games       map[string]*Game
+	store       GameStore       // Постоянное хранилище, nil — только в памяти
+	botPending  map[string]bool // Игры, в которых уже запланирован ход бота
+	botFailures map[string]int  // Сколько действий подряд бота было отклонено в игре
+	mutex       sync.RWMutex
 }
 
 // Message для WebSocket коммуникации
@@ -64,26 +63,15 @@ type Message struct {
 	Data interface{} `json:"data"`
 }
 
-// MoveData для передачи хода в крестики-нолики
-type MoveData struct {
-	GameID   string `json:"gameId"`
-	Position int    `json:"position"`
-	PlayerID string `json:"playerId"`
-}
-
-// AttackData для атаки в морском бое
-type AttackData struct {
-	GameID   string `json:"gameId"`
-	X        int    `json:"x"`
-	Y        int    `json:"y"`
-	PlayerID string `json:"playerId"`
+// AuthData для привязки WebSocket-подключения к сессии
+type AuthData struct {
+	Token string `json:"token"`
 }
 
-// ShipPlacementData для размещения кораблей
-type ShipPlacementData struct {
-	GameID   string `json:"gameId"`
-	PlayerID string `json:"playerId"`
-	Ships    []Ship `json:"ships"`
+// GameResponse ответ на создание игры и присоединение к ней
+type GameResponse struct {
+	Game  *Game  `json:"game"`
+	Token string `json:"token"` // Токен сессии для WebSocket
 }
 
 // RestartVoteData для голосования за повтор
@@ -94,7 +82,9 @@ type RestartVoteData struct {
 
 var (
 	gameManager = &GameManager{
-		games: make(map[string]*Game),
+		games:       make(map[string]*Game),
+		botPending:  make(map[string]bool),
+		botFailures: make(map[string]int),
 	}
 	upgrader = websocket.Upgrader{
 		CheckOrigin: func(r *http.Request) bool {
@@ -122,17 +112,108 @@ func cleanupOldGames() {
 		gameManager.mutex.Lock()
 		now := time.Now()
 		for id, game := range gameManager.games {
-			if now.Sub(game.Created) > 2*time.Hour {
-				delete(gameManager.games, id)
+			// Игры-серии идут дольше двух часов, поэтому удаляются только заброшенные игры
+			if now.Sub(game.lastActivity()) > 2*time.Hour {
+				gameManager.removeGameLocked(id)
 				log.Printf("Удалена старая игра: %s", id)
 			}
 		}
 		gameManager.mutex.Unlock()
+
+		sessionStore.cleanup()
+	}
+}
+
+// lastActivity возвращает время последнего действия в игре: хода, начала
+// партии или создания игры
+func (g *Game) lastActivity() time.Time {
+	last := g.Created
+	if g.Started.After(last) {
+		last = g.Started
+	}
+	if len(g.Events) > 0 && g.Events[len(g.Events)-1].Time.After(last) {
+		last = g.Events[len(g.Events)-1].Time
+	}
+	return last
+}
+
+// playerIndex возвращает индекс игрока в игре или -1, если он не участвует
+func (g *Game) playerIndex(playerID string) int {
+	for i, player := range g.Players {
+		if player.ID == playerID {
+			return i
+		}
 	}
+	return -1
 }
 
-// createGame создает новую игру
-func (gm *GameManager) createGame(playerID, playerName, gameType string) *Game {
+// removeGame удаляет игру из памяти и хранилища
+func (gm *GameManager) removeGame(gameID string) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+	gm.removeGameLocked(gameID)
+}
+
+// removeGameLocked удаляет игру из памяти и хранилища. Вызывается под блокировкой
+func (gm *GameManager) removeGameLocked(gameID string) {
+	delete(gm.games, gameID)
+	delete(gm.botFailures, gameID)
+	if gm.store != nil {
+		if err := gm.store.Delete(gameID); err != nil {
+			log.Printf("Ошибка удаления игры %s из хранилища: %v", gameID, err)
+		}
+	}
+}
+
+// persist сохраняет игру в хранилище. Вызывается под блокировкой после каждого изменения
+func (gm *GameManager) persist(game *Game) {
+	if gm.store == nil {
+		return
+	}
+	if err := gm.store.Save(game); err != nil {
+		log.Printf("Ошибка сохранения игры %s: %v", game.ID, err)
+	}
+}
+
+// load загружает сохраненные игры при запуске сервера
+func (gm *GameManager) load() error {
+	if gm.store == nil {
+		return nil
+	}
+
+	games, err := gm.store.LoadAll()
+	if err != nil {
+		return err
+	}
+
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
+
+	for _, game := range games {
+		gm.games[game.ID] = game
+		gm.scheduleBots(game)
+	}
+	log.Printf("Загружено игр из хранилища: %d", len(games))
+	return nil
+}
+
+// createGame создает новую игру. bestOf 3, 5 или 7 создает серию партий, 0 — одну партию
+func (gm *GameManager) createGame(playerID, playerName, gameType string, options json.RawMessage, bestOf int) (*Game, error) {
+	engine, exists := engineFor(gameType)
+	if !exists {
+		return nil, fmt.Errorf("неверный тип игры")
+	}
+
+	state, err := validateOptions(engine, options)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := newSeries(bestOf)
+	if err != nil {
+		return nil, err
+	}
+
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
@@ -144,29 +225,21 @@ func (gm *GameManager) createGame(playerID, playerName, gameType string) *Game {
 	game := &Game{
 		ID:           gameID,
 		Type:         gameType,
-		Players:      []Player{{ID: playerID, Name: playerName, Symbol: "X"}},
+		Options:      options,
+		State:        state,
+		Players:      []Player{{ID: playerID, Name: playerName, Symbol: engine.Symbol(0)}},
 		Turn:         0,
 		Status:       "waiting",
 		Created:      time.Now(),
+		Round:        1,
+		Series:       series,
 		RestartVotes: []string{},
 	}
 
-	if gameType == "tictactoe" {
-		game.Board = [9]string{}
-	} else if gameType == "battleship" {
-		game.Boards = make([]Board, 2)
-		for i := range game.Boards {
-			game.Boards[i] = Board{
-				Grid:  [10][10]string{},
-				Ships: []Ship{},
-				Ready: false,
-			}
-		}
-	}
-
 	gm.games[gameID] = game
+	gm.persist(game)
 	log.Printf("Создана игра %s (%s) игроком %s", gameID, gameType, playerName)
-	return game
+	return game, nil
 }
 
 // joinGame присоединяет игрока к игре
@@ -193,26 +266,20 @@ func (gm *GameManager) joinGame(gameID, playerID, playerName string) (*Game, err
 		}
 	}
 
-	symbol := "O"
-	if game.Type == "battleship" {
-		symbol = ""
-	}
-
+	engine, _ := engineFor(game.Type)
 	game.Players = append(game.Players, Player{
 		ID:     playerID,
 		Name:   playerName,
-		Symbol: symbol,
+		Symbol: engine.Symbol(len(game.Players)),
 	})
 
 	if len(game.Players) == 2 {
-		if game.Type == "tictactoe" {
-			game.Status = "playing"
-		} else if game.Type == "battleship" {
-			game.Status = "setup" // Фаза расстановки кораблей
-		}
+		game.Status = engine.StartStatus()
+		game.Started = time.Now()
 		log.Printf("Игра %s (%s) началась: %s vs %s", gameID, game.Type, game.Players[0].Name, game.Players[1].Name)
 	}
 
+	gm.persist(game)
 	return game, nil
 }
 
@@ -226,27 +293,8 @@ func (gm *GameManager) restartGame(gameID string) (*Game, error) {
 		return nil, fmt.Errorf("игра не найдена")
 	}
 
-	// Сбрасываем состояние игры
-	if game.Type == "tictactoe" {
-		game.Board = [9]string{}
-		game.Status = "playing"
-	} else if game.Type == "battleship" {
-		for i := range game.Boards {
-			game.Boards[i] = Board{
-				Grid:  [10][10]string{},
-				Ships: []Ship{},
-				Ready: false,
-			}
-		}
-		game.Status = "setup"
-	}
-
-	game.Turn = 0
-	game.Winner = ""
-	game.RestartVotes = []string{}
-
 	log.Printf("Игра %s перезапущена", gameID)
-	return game, nil
+	return gm.restartGameInternal(game)
 }
 
 // voteRestart голосует за перезапуск игры
@@ -259,10 +307,14 @@ func (gm *GameManager) voteRestart(gameID, playerID string) (*Game, error) {
 		return nil, fmt.Errorf("игра не найдена")
 	}
 
-	if game.Status != "finished" {
+	if game.Status != "finished" && game.Status != "restart_requested" {
 		return nil, fmt.Errorf("игра не завершена")
 	}
 
+	if game.Series.finished() {
+		return nil, fmt.Errorf("серия завершена")
+	}
+
 	// Проверяем, не голосовал ли уже этот игрок
 	for _, vote := range game.RestartVotes {
 		if vote == playerID {
@@ -272,39 +324,45 @@ func (gm *GameManager) voteRestart(gameID, playerID string) (*Game, error) {
 
 	game.RestartVotes = append(game.RestartVotes, playerID)
 
+	// Боты всегда согласны сыграть еще раз
+	for _, player := range game.Players {
+		if player.Bot != "" && !slices.Contains(game.RestartVotes, player.ID) {
+			game.RestartVotes = append(game.RestartVotes, player.ID)
+		}
+	}
+
 	// Если все игроки проголосовали, перезапускаем игру
 	if len(game.RestartVotes) == len(game.Players) {
 		return gm.restartGameInternal(game)
 	}
 
 	game.Status = "restart_requested"
+	gm.persist(game)
 	return game, nil
 }
 
 func (gm *GameManager) restartGameInternal(game *Game) (*Game, error) {
-	if game.Type == "tictactoe" {
-		game.Board = [9]string{}
-		game.Status = "playing"
-	} else if game.Type == "battleship" {
-		for i := range game.Boards {
-			game.Boards[i] = Board{
-				Grid:  [10][10]string{},
-				Ships: []Ship{},
-				Ready: false,
-			}
-		}
-		game.Status = "setup"
+	engine, _ := engineFor(game.Type)
+	if game.Series != nil {
+		game.rotateSeats(engine)
 	}
+	engine.Reset(game)
 
+	game.Events = nil
+	game.Status = engine.StartStatus()
+	game.Started = time.Now()
+	game.Round = max(game.Round, 1) + 1
 	game.Turn = 0
 	game.Winner = ""
 	game.RestartVotes = []string{}
 
+	gm.persist(game)
+	gm.scheduleBots(game)
 	return game, nil
 }
 
-// makeMove делает ход в крестики-нолики
-func (gm *GameManager) makeMove(gameID, playerID string, position int) (*Game, error) {
+// applyAction проверяет и применяет действие игрока через движок игры
+func (gm *GameManager) applyAction(gameID, playerID, action string, data json.RawMessage) (*Game, error) {
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
@@ -313,114 +371,88 @@ func (gm *GameManager) makeMove(gameID, playerID string, position int) (*Game, e
 		return nil, fmt.Errorf("игра не найдена")
 	}
 
-	if game.Type != "tictactoe" {
-		return nil, fmt.Errorf("неверный тип игры")
-	}
-
-	if game.Status != "playing" {
-		return nil, fmt.Errorf("игра не активна")
-	}
-
-	if position < 0 || position > 8 {
-		return nil, fmt.Errorf("неверная позиция")
-	}
-
-	if game.Board[position] != "" {
-		return nil, fmt.Errorf("позиция уже занята")
+	playerIndex := game.playerIndex(playerID)
+	if playerIndex == -1 {
+		return nil, fmt.Errorf("игрок не найден")
 	}
 
-	currentPlayer := game.Players[game.Turn]
-	if currentPlayer.ID != playerID {
-		return nil, fmt.Errorf("не ваш ход")
+	if err := gm.applyActionLocked(game, playerIndex, action, data); err != nil {
+		return nil, err
 	}
-
-	game.Board[position] = currentPlayer.Symbol
-
-	if winner := checkWinnerTicTacToe(game.Board); winner != "" {
-		game.Status = "finished"
-		game.Winner = winner
-		log.Printf("Игра %s завершена, победитель: %s", gameID, winner)
-	} else if isBoardFull(game.Board) {
-		game.Status = "finished"
-		game.Winner = "draw"
-		log.Printf("Игра %s завершена ничьей", gameID)
-	} else {
-		game.Turn = 1 - game.Turn
-	}
-
 	return game, nil
 }
 
-// placeShips размещает корабли для морского боя
-func (gm *GameManager) placeShips(gameID, playerID string, ships []Ship) (*Game, error) {
-	gm.mutex.Lock()
-	defer gm.mutex.Unlock()
-
-	game, exists := gm.games[gameID]
-	if !exists {
-		return nil, fmt.Errorf("игра не найдена")
-	}
-
-	if game.Type != "battleship" {
-		return nil, fmt.Errorf("неверный тип игры")
-	}
-
-	if game.Status != "setup" {
-		return nil, fmt.Errorf("фаза расстановки завершена")
+// applyActionLocked применяет действие игрока с индексом playerIndex. Вызывается под блокировкой
+func (gm *GameManager) applyActionLocked(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	engine, _ := engineFor(game.Type)
+	if normalizer, ok := engine.(ActionNormalizer); ok {
+		normalized, err := normalizer.NormalizeAction(game, playerIndex, action, data)
+		if err != nil {
+			return err
+		}
+		data = normalized
 	}
 
-	// Найдем индекс игрока
-	playerIndex := -1
-	for i, player := range game.Players {
-		if player.ID == playerID {
-			playerIndex = i
-			break
-		}
+	if err := engine.Apply(game, playerIndex, action, data); err != nil {
+		return err
 	}
+	game.recordEvent(playerIndex, action, data)
 
-	if playerIndex == -1 {
-		return nil, fmt.Errorf("игрок не найден")
+	if finished, winner := engine.Outcome(game); finished {
+		gm.finishLocked(game, winner)
+	} else {
+		gm.persist(game)
 	}
+	gm.scheduleBots(game)
+	return nil
+}
 
-	// Проверяем корректность расстановки кораблей
-	if !validateShipPlacement(ships) {
-		return nil, fmt.Errorf("некорректная расстановка кораблей")
+// finishLocked завершает партию, учитывает ее итог в рейтингах, профилях,
+// архиве и счете серии и сохраняет игру. Все записи в хранилище идут одной
+// транзакцией. Вызывается под блокировкой
+func (gm *GameManager) finishLocked(game *Game, winner string) {
+	game.Status = "finished"
+	game.Winner = winner
+	if winner == "draw" {
+		log.Printf("Игра %s завершена ничьей", game.ID)
+	} else {
+		log.Printf("Игра %s завершена, победитель: %s", game.ID, winner)
 	}
 
-	// Размещаем корабли
-	game.Boards[playerIndex].Ships = ships
-	game.Boards[playerIndex].Ready = true
+	gm.batch(func() {
+		ratingBook.recordGame(game)
+		profiles.recordGame(game)
+		matchArchive.record(game, time.Now())
+		game.Series.record(game)
+		gm.persist(game)
+	})
+}
 
-	// Обновляем сетку
-	for i := range game.Boards[playerIndex].Grid {
-		for j := range game.Boards[playerIndex].Grid[i] {
-			game.Boards[playerIndex].Grid[i][j] = ""
-		}
+// batch выполняет fn, объединяя записи в хранилище в одну транзакцию, если хранилище это умеет
+func (gm *GameManager) batch(fn func()) {
+	batcher, ok := gm.store.(BatchStore)
+	if !ok {
+		fn()
+		return
 	}
-
-	for _, ship := range ships {
-		for i := 0; i < ship.Length; i++ {
-			x, y := ship.X, ship.Y
-			if ship.Direction == "horizontal" {
-				x += i
-			} else {
-				y += i
-			}
-			game.Boards[playerIndex].Grid[y][x] = "ship"
-		}
+	if err := batcher.Batch(fn); err != nil {
+		log.Printf("Ошибка сохранения в хранилище: %v", err)
 	}
+}
 
-	// Если оба игрока готовы, начинаем игру
-	if len(game.Players) == 2 && game.Boards[0].Ready && game.Boards[1].Ready {
-		game.Status = "playing"
-		log.Printf("Игра морской бой %s началась", gameID)
+// forfeitLocked завершает партию поражением игрока с индексом loser. Вызывается под блокировкой
+func (gm *GameManager) forfeitLocked(game *Game, loser int) {
+	engine, _ := engineFor(game.Type)
+	winner := engine.Symbol(1 - loser)
+	if winner == "" {
+		// У игроков нет символов (морской бой): победитель обозначается местом
+		winner = fmt.Sprintf("player%d", 2-loser)
 	}
-
-	return game, nil
+	gm.finishLocked(game, winner)
 }
 
-// attack делает атаку в морском бое
-func (gm *GameManager) attack(gameID, playerID string, x, y int) (*Game, error) {
+// attachClient привязывает подключение к месту игрока в игре
+func (gm *GameManager) attachClient(gameID string, client *Client) (*Game, error) {
 	gm.mutex.Lock()
 	defer gm.mutex.Unlock()
 
@@ -429,178 +461,70 @@ func (gm *GameManager) attack(gameID, playerID string, x, y int) (*Game, error)
 		return nil, fmt.Errorf("игра не найдена")
 	}
 
-	if game.Type != "battleship" {
-		return nil, fmt.Errorf("неверный тип игры")
-	}
-
-	if game.Status != "playing" {
-		return nil, fmt.Errorf("игра не активна")
-	}
-
-	if x < 0 || x > 9 || y < 0 || y > 9 {
-		return nil, fmt.Errorf("неверные координаты")
-	}
-
-	currentPlayer := game.Players[game.Turn]
-	if currentPlayer.ID != playerID {
-		return nil, fmt.Errorf("не ваш ход")
-	}
-
-	// Индекс противника
-	targetIndex := 1 - game.Turn
-	target := &game.Boards[targetIndex]
-
-	// Проверяем, не атаковали ли уже эту клетку
-	if target.Grid[y][x] == "hit" || target.Grid[y][x] == "miss" {
-		return nil, fmt.Errorf("клетка уже атакована")
-	}
-
-	hit := false
-	if target.Grid[y][x] == "ship" {
-		target.Grid[y][x] = "hit"
-		hit = true
-
-		// Проверяем, потоплен ли корабль
-		for i, ship := range target.Ships {
-			if isShipHit(&ship, x, y) {
-				target.Ships[i].Hits++
-				if target.Ships[i].Hits >= ship.Length {
-					log.Printf("Корабль потоплен в игре %s", gameID)
-				}
-				break
-			}
-		}
-
-		// Проверяем победу
-		if allShipsSunk(target.Ships) {
-			game.Status = "finished"
-			if game.Turn == 0 {
-				game.Winner = "player1"
-			} else {
-				game.Winner = "player2"
-			}
-			log.Printf("Игра морской бой %s завершена, победитель: %s", gameID, game.Winner)
-		}
-	} else {
-		target.Grid[y][x] = "miss"
-	}
-
-	// Если промах, передаем ход
-	if !hit && game.Status == "playing" {
-		game.Turn = 1 - game.Turn
+	playerIndex := game.playerIndex(client.session.Player.ID)
+	if playerIndex == -1 {
+		return nil, fmt.Errorf("вы не участвуете в этой игре")
 	}
 
-	return game, nil
+	game.Players[playerIndex].Client = client
+	client.addGame(gameID)
+	log.Printf("Игрок %s подключился к игре %s", game.Players[playerIndex].Name, gameID)
+	return gameView(game, client.session.Player.ID), nil
 }
 
-// Вспомогательные функции
-
-func validateShipPlacement(ships []Ship) bool {
-	// Проверяем количество кораблей: 1x4, 2x3, 3x2, 4x1
-	shipCounts := map[int]int{4: 0, 3: 0, 2: 0, 1: 0}
-	requiredCounts := map[int]int{4: 1, 3: 2, 2: 3, 1: 4}
-
-	grid := [10][10]bool{}
+// detachClient отвязывает закрытое подключение от всех его игр
+func (gm *GameManager) detachClient(client *Client) {
+	gm.mutex.Lock()
+	defer gm.mutex.Unlock()
 
-	for _, ship := range ships {
-		if ship.Length < 1 || ship.Length > 4 {
-			return false
+	for _, gameID := range client.joinedGames() {
+		game, exists := gm.games[gameID]
+		if !exists {
+			continue
 		}
-		shipCounts[ship.Length]++
-
-		// Проверяем границы и пересечения
-		for i := 0; i < ship.Length; i++ {
-			x, y := ship.X, ship.Y
-			if ship.Direction == "horizontal" {
-				x += i
-			} else {
-				y += i
-			}
-
-			if x < 0 || x > 9 || y < 0 || y > 9 {
-				return false
+		for i := range game.Players {
+			if game.Players[i].Client == client {
+				game.Players[i].Client = nil
 			}
-
-			if grid[y][x] {
-				return false // Пересечение
-			}
-
-			// Проверяем соседние клетки
-			for dx := -1; dx <= 1; dx++ {
-				for dy := -1; dy <= 1; dy++ {
-					nx, ny := x+dx, y+dy
-					if nx >= 0 && nx < 10 && ny >= 0 && ny < 10 && grid[ny][nx] {
-						return false // Корабли касаются
-					}
-				}
-			}
-
-			grid[y][x] = true
 		}
 	}
-
-	// Проверяем количество кораблей каждого типа
-	for length, required := range requiredCounts {
-		if shipCounts[length] != required {
-			return false
-		}
-	}
-
-	return true
 }
 
-func isShipHit(ship *Ship, x, y int) bool {
-	for i := 0; i < ship.Length; i++ {
-		sx, sy := ship.X, ship.Y
-		if ship.Direction == "horizontal" {
-			sx += i
-		} else {
-			sy += i
-		}
-		if sx == x && sy == y {
-			return true
-		}
-	}
-	return false
-}
+// view возвращает представление игры для игрока
+func (gm *GameManager) view(gameID, playerID string) (*Game, bool) {
+	gm.mutex.RLock()
+	defer gm.mutex.RUnlock()
 
-func allShipsSunk(ships []Ship) bool {
-	for _, ship := range ships {
-		if ship.Hits < ship.Length {
-			return false
-		}
+	game, exists := gm.games[gameID]
+	if !exists {
+		return nil, false
 	}
-	return true
+	return gameView(game, playerID), true
 }
 
-func checkWinnerTicTacToe(board [9]string) string {
-	wins := [][]int{
-		{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
-		{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
-		{0, 4, 8}, {2, 4, 6},
+// replay проигрывает журнал завершенной партии
+func (gm *GameManager) replay(gameID string) (*Replay, error) {
+	gm.mutex.RLock()
+	game, exists := gm.games[gameID]
+	if !exists {
+		gm.mutex.RUnlock()
+		return nil, errGameNotFound
 	}
-
-	for _, win := range wins {
-		if board[win[0]] != "" &&
-			board[win[0]] == board[win[1]] &&
-			board[win[1]] == board[win[2]] {
-			return board[win[0]]
-		}
+	if game.Status != "finished" && game.Status != "restart_requested" {
+		gm.mutex.RUnlock()
+		return nil, fmt.Errorf("игра еще не завершена")
 	}
-	return ""
-}
+	gameType := game.Type
+	options := game.Options
+	players := append([]Player{}, game.Players...)
+	events := append([]GameEvent{}, game.Events...)
+	gm.mutex.RUnlock()
 
-func isBoardFull(board [9]string) bool {
-	for _, cell := range board {
-		if cell == "" {
-			return false
-		}
-	}
-	return true
+	return replayGame(gameID, gameType, options, players, events)
 }
 
-// broadcastToGame отправляет сообщение всем игрокам в игре
-func (gm *GameManager) broadcastToGame(gameID string, message Message) {
+// broadcastGameUpdate отправляет каждому игроку его представление игры
+func (gm *GameManager) broadcastGameUpdate(gameID string) {
 	gm.mutex.RLock()
 	defer gm.mutex.RUnlock()
 
@@ -610,10 +534,14 @@ func (gm *GameManager) broadcastToGame(gameID string, message Message) {
 	}
 
 	for i, player := range game.Players {
-		if player.Conn != nil {
-			if err := player.Conn.WriteJSON(message); err != nil {
+		if player.Client != nil {
+			message := Message{
+				Type: "gameUpdate",
+				Data: gameView(game, player.ID),
+			}
+			if err := player.Client.send(message); err != nil {
 				log.Printf("Ошибка отправки сообщения игроку %s: %v", player.ID, err)
-				game.Players[i].Conn = nil
+				game.Players[i].Client = nil
 			}
 		}
 	}
@@ -638,9 +566,13 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 
 func createGameHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		PlayerID   string `json:"playerId"`
-		PlayerName string `json:"playerName"`
-		GameType   string `json:"gameType"` // "tictactoe" или "battleship"
+		PlayerID   string          `json:"playerId"`
+		PlayerName string          `json:"playerName"`
+		GameType   string          `json:"gameType"`   // Тип игры из реестра движков, по умолчанию "tictactoe"
+		Opponent   string          `json:"opponent"`   // "bot" — играть против бота
+		Difficulty string          `json:"difficulty"` // "easy", "medium" или "impossible"
+		Options    json.RawMessage `json:"options"`    // Настройки партии, их разбирает движок игры
+		BestOf     int             `json:"bestOf"`     // 3, 5 или 7 — серия партий; 0 — одна партия
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -648,24 +580,70 @@ func createGameHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.PlayerID == "" || req.PlayerName == "" {
-		http.Error(w, "Не указан ID или имя игрока", http.StatusBadRequest)
+	identity, err := authenticate(r, req.PlayerID, req.PlayerName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
+	profiles.touch(identity)
 
 	if req.GameType == "" {
 		req.GameType = "tictactoe"
 	}
 
-	if req.GameType != "tictactoe" && req.GameType != "battleship" {
-		http.Error(w, "Неверный тип игры", http.StatusBadRequest)
+	if req.Opponent == "bot" {
+		if req.Difficulty == "" {
+			req.Difficulty = "medium"
+		}
+		if _, err := newBot(req.GameType, req.Difficulty); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	game, err := gameManager.createGame(identity.ID, identity.Name, req.GameType, req.Options, req.BestOf)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.Opponent == "bot" {
+		if _, err := gameManager.seatBot(game.ID, req.Difficulty); err != nil {
+			gameManager.removeGame(game.ID)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	view, _ := gameManager.view(game.ID, identity.ID)
+	session := sessionStore.issue(identity)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(GameResponse{Game: view, Token: session.Token})
+}
+
+// authHandler выдает токен сессии по данным Telegram
+func authHandler(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PlayerID   string `json:"playerId"`
+		PlayerName string `json:"playerName"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	identity, err := authenticate(r, req.PlayerID, req.PlayerName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
+	profiles.touch(identity)
 
-	game := gameManager.createGame(req.PlayerID, req.PlayerName, req.GameType)
+	session := sessionStore.issue(identity)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(game)
+	json.NewEncoder(w).Encode(map[string]string{
+		"token":      session.Token,
+		"playerId":   identity.ID,
+		"playerName": identity.Name,
+	})
 }
 
 func joinGameHandler(w http.ResponseWriter, r *http.Request) {
@@ -680,24 +658,29 @@ func joinGameHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.GameID == "" || req.PlayerID == "" || req.PlayerName == "" {
+	if req.GameID == "" {
 		http.Error(w, "Не указаны обязательные поля", http.StatusBadRequest)
 		return
 	}
 
-	game, err := gameManager.joinGame(req.GameID, req.PlayerID, req.PlayerName)
+	identity, err := authenticate(r, req.PlayerID, req.PlayerName)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	profiles.touch(identity)
+
+	if _, err := gameManager.joinGame(req.GameID, identity.ID, identity.Name); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	gameManager.broadcastToGame(req.GameID, Message{
-		Type: "gameUpdate",
-		Data: game,
-	})
+	gameManager.broadcastGameUpdate(req.GameID)
+	view, _ := gameManager.view(req.GameID, identity.ID)
+	session := sessionStore.issue(identity)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(game)
+	json.NewEncoder(w).Encode(GameResponse{Game: view, Token: session.Token})
 }
 
 func getGameHandler(w http.ResponseWriter, r *http.Request) {
@@ -709,17 +692,39 @@ func getGameHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	gameManager.mutex.RLock()
-	game, exists := gameManager.games[gameID]
-	gameManager.mutex.RUnlock()
+	// Представление строится для того, кто спрашивает: чужие корабли скрыты.
+	// Без авторизации игра видна как наблюдателю
+	playerID := ""
+	if identity, err := authenticate(r, r.URL.Query().Get("playerId"), ""); err == nil {
+		playerID = identity.ID
+	}
 
+	view, exists := gameManager.view(gameID, playerID)
 	if !exists {
 		http.Error(w, "Игра не найдена", http.StatusNotFound)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(game)
+	json.NewEncoder(w).Encode(view)
+}
+
+// replayHandler отдает журнал событий завершенной игры для пошагового просмотра
+func replayHandler(w http.ResponseWriter, r *http.Request) {
+	gameID := mux.Vars(r)["gameId"]
+
+	replay, err := gameManager.replay(gameID)
+	if errors.Is(err, errGameNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(replay)
 }
 
 func websocketHandler(w http.ResponseWriter, r *http.Request) {
@@ -732,6 +737,11 @@ func websocketHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
+	client := newClient(conn)
+	defer gameManager.detachClient(client)
+	defer matchmaker.removeClient(client)
+	defer client.markClosed() // Выполняется первым: подбор больше не выберет это подключение
+
 	for {
 		var msg Message
 		if err := conn.ReadJSON(&msg); err != nil {
@@ -741,7 +751,32 @@ func websocketHandler(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
+		// До предъявления токена подключение не может ничего делать
+		if msg.Type != "auth" && client.session == nil {
+			client.sendError(fmt.Errorf("требуется авторизация"))
+			continue
+		}
+
 		switch msg.Type {
+		case "auth":
+			data, _ := json.Marshal(msg.Data)
+			var authData AuthData
+			if err := json.Unmarshal(data, &authData); err != nil {
+				continue
+			}
+
+			if client.session != nil {
+				client.sendError(fmt.Errorf("подключение уже авторизовано"))
+				continue
+			}
+
+			session, exists := sessionStore.lookup(authData.Token)
+			if !exists {
+				client.sendError(fmt.Errorf("сессия недействительна"))
+				continue
+			}
+			client.session = session
+
 		case "join":
 			data, _ := json.Marshal(msg.Data)
 			var joinData struct {
@@ -751,106 +786,111 @@ func websocketHandler(w http.ResponseWriter, r *http.Request) {
 			if err := json.Unmarshal(data, &joinData); err != nil {
 				continue
 			}
-
-			gameManager.mutex.Lock()
-			if game, exists := gameManager.games[joinData.GameID]; exists {
-				for i, player := range game.Players {
-					if player.ID == joinData.PlayerID {
-						game.Players[i].Conn = conn
-						log.Printf("Игрок %s подключился к игре %s", player.Name, joinData.GameID)
-						break
-					}
-				}
-			}
-			gameManager.mutex.Unlock()
-
-		case "move":
-			data, _ := json.Marshal(msg.Data)
-			var moveData MoveData
-			if err := json.Unmarshal(data, &moveData); err != nil {
+			if err := checkPlayerID(&client.session.Player, joinData.PlayerID); err != nil {
+				client.sendError(err)
 				continue
 			}
 
-			game, err := gameManager.makeMove(moveData.GameID, moveData.PlayerID, moveData.Position)
+			view, err := gameManager.attachClient(joinData.GameID, client)
 			if err != nil {
-				conn.WriteJSON(Message{
-					Type: "error",
-					Data: map[string]string{"message": err.Error()},
-				})
+				client.sendError(err)
 				continue
 			}
 
-			gameManager.broadcastToGame(moveData.GameID, Message{
+			client.send(Message{
 				Type: "gameUpdate",
-				Data: game,
+				Data: view,
 			})
 
-		case "attack":
+		case "queue":
 			data, _ := json.Marshal(msg.Data)
-			var attackData AttackData
-			if err := json.Unmarshal(data, &attackData); err != nil {
+			var queueData QueueData
+			if err := json.Unmarshal(data, &queueData); err != nil {
+				client.sendError(fmt.Errorf("некорректная заявка"))
+				continue
+			}
+			if err := checkPlayerID(&client.session.Player, queueData.PlayerID); err != nil {
+				client.sendError(err)
 				continue
 			}
 
-			game, err := gameManager.attack(attackData.GameID, attackData.PlayerID, attackData.X, attackData.Y)
+			position, err := matchmaker.enqueue(client, queueData)
 			if err != nil {
-				conn.WriteJSON(Message{
-					Type: "error",
-					Data: map[string]string{"message": err.Error()},
-				})
+				client.sendError(err)
 				continue
 			}
+			if position == 0 {
+				continue // Соперник уже найден, игра отправлена в matchFound
+			}
 
-			gameManager.broadcastToGame(attackData.GameID, Message{
-				Type: "gameUpdate",
-				Data: game,
+			client.send(Message{
+				Type: "queued",
+				Data: map[string]interface{}{
+					"position": position,
+					"timeout":  int(queueTimeout.Seconds()),
+				},
 			})
 
-		case "placeShips":
+		case "cancelQueue":
+			if matchmaker.cancel(client.session.Player.ID) {
+				client.send(Message{
+					Type: "queueLeft",
+					Data: map[string]string{"reason": "cancelled"},
+				})
+			}
+
+		case "restartVote":
 			data, _ := json.Marshal(msg.Data)
-			var shipData ShipPlacementData
-			if err := json.Unmarshal(data, &shipData); err != nil {
+			var restartData RestartVoteData
+			if err := json.Unmarshal(data, &restartData); err != nil {
+				continue
+			}
+			if err := client.authorize(restartData.GameID, restartData.PlayerID); err != nil {
+				client.sendError(err)
 				continue
 			}
 
-			game, err := gameManager.placeShips(shipData.GameID, shipData.PlayerID, shipData.Ships)
-			if err != nil {
-				conn.WriteJSON(Message{
-					Type: "error",
-					Data: map[string]string{"message": err.Error()},
-				})
+			if _, err := gameManager.voteRestart(restartData.GameID, client.session.Player.ID); err != nil {
+				client.sendError(err)
 				continue
 			}
 
-			gameManager.broadcastToGame(shipData.GameID, Message{
-				Type: "gameUpdate",
-				Data: game,
-			})
+			gameManager.broadcastGameUpdate(restartData.GameID)
 
-		case "restartVote":
+		default:
+			// Остальные сообщения — действия в игре, их разбирает движок
 			data, _ := json.Marshal(msg.Data)
-			var restartData RestartVoteData
-			if err := json.Unmarshal(data, &restartData); err != nil {
+			var target struct {
+				GameID   string `json:"gameId"`
+				PlayerID string `json:"playerId"`
+			}
+			if err := json.Unmarshal(data, &target); err != nil {
+				continue
+			}
+			if err := client.authorize(target.GameID, target.PlayerID); err != nil {
+				client.sendError(err)
 				continue
 			}
 
-			game, err := gameManager.voteRestart(restartData.GameID, restartData.PlayerID)
-			if err != nil {
-				conn.WriteJSON(Message{
-					Type: "error",
-					Data: map[string]string{"message": err.Error()},
-				})
+			if _, err := gameManager.applyAction(target.GameID, client.session.Player.ID, msg.Type, data); err != nil {
+				client.sendError(err)
 				continue
 			}
 
-			gameManager.broadcastToGame(restartData.GameID, Message{
-				Type: "gameUpdate",
-				Data: game,
-			})
+			gameManager.broadcastGameUpdate(target.GameID)
 		}
 	}
 }
 
+// checkPlayerID сверяет ID игрока из сообщения с подтвержденной личностью.
+// Пустой ID допускается: действие выполняется от имени подключения
+func checkPlayerID(identity *Identity, claimedID string) error {
+	if claimedID != "" && claimedID != identity.ID {
+		return fmt.Errorf("нельзя действовать от имени другого игрока")
+	}
+	return nil
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -876,15 +916,63 @@ func getPort() string {
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
+	if authConfig.BotToken == "" {
+		log.Printf("TELEGRAM_BOT_TOKEN не задан: вход через Telegram недоступен")
+	}
+	if authConfig.DevMode {
+		log.Printf("Режим разработки: разрешены тестовые игроки test_*")
+	}
+
+	store, err := openBoltStore(getDBPath())
+	if err != nil {
+		log.Fatalf("Ошибка открытия хранилища: %v", err)
+	}
+	defer store.Close()
+
+	gameManager.store = store
+	if err := gameManager.load(); err != nil {
+		log.Fatalf("Ошибка загрузки игр: %v", err)
+	}
+
+	ratingBook.store = store
+	if err := ratingBook.load(); err != nil {
+		log.Fatalf("Ошибка загрузки рейтингов: %v", err)
+	}
+
+	leaderboards.store = store
+	if err := leaderboards.load(); err != nil {
+		log.Fatalf("Ошибка загрузки таблиц лидеров: %v", err)
+	}
+
+	profiles.store = store
+	if err := profiles.load(); err != nil {
+		log.Fatalf("Ошибка загрузки профилей: %v", err)
+	}
+
+	matchArchive.store = store
+	if err := matchArchive.load(); err != nil {
+		log.Fatalf("Ошибка загрузки архива партий: %v", err)
+	}
+
 	go cleanupOldGames()
+	go matchmaker.run()
 
 	r := mux.NewRouter()
 	r.HandleFunc("/health", healthHandler).Methods("GET")
 
 	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/auth", authHandler).Methods("POST")
 	api.HandleFunc("/games", createGameHandler).Methods("POST")
 	api.HandleFunc("/games/join", joinGameHandler).Methods("POST")
 	api.HandleFunc("/games/{gameId}", getGameHandler).Methods("GET")
+	api.HandleFunc("/games/{gameId}/replay", replayHandler).Methods("GET")
+	api.HandleFunc("/games/{gameId}/pgn", pgnHandler).Methods("GET")
+	api.HandleFunc("/players/{id}", profileHandler).Methods("GET")
+	api.HandleFunc("/players/{id}/rating", ratingHandler).Methods("GET")
+	api.HandleFunc("/players/{id}/games", historyHandler).Methods("GET")
+	api.HandleFunc("/matches/{matchId}/replay", matchReplayHandler).Methods("GET")
+	api.HandleFunc("/leaderboard", leaderboardHandler).Methods("GET")
+	api.HandleFunc("/battleship/fleet", randomFleetHandler).Methods("GET")
 	api.HandleFunc("/ws", websocketHandler)
 
 	r.PathPrefix("/").Handler(http.FileServer(http.Dir("./static/")))
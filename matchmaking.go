@@ -0,0 +1,350 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// queueTimeout сколько игрок может ждать соперника
+	queueTimeout = 2 * time.Minute
+	// ratingWindow допустимая разница рейтингов сразу после входа в очередь
+	ratingWindow = 100.0
+	// ratingWindowGrowth на сколько окно рейтингов расширяется за секунду ожидания
+	ratingWindowGrowth = 10.0
+	// defaultRating рейтинг игрока, который еще не играл
+	defaultRating = 1500.0
+)
+
+// QueueData для постановки в очередь поиска соперника
+type QueueData struct {
+	GameType string          `json:"gameType"`
+	Options  json.RawMessage `json:"options"` // Вариант правил: соперники подбираются с теми же настройками
+	Mode     string          `json:"mode"`    // "fifo" (по умолчанию) или "rating"
+	PlayerID string          `json:"playerId"`
+}
+
+// QueueEntry заявка игрока в очереди
+type QueueEntry struct {
+	PlayerID   string
+	PlayerName string
+	GameType   string
+	Options    json.RawMessage
+	Mode       string
+	Rating     float64
+	Joined     time.Time
+	client     *Client
+}
+
+// Matchmaker очереди поиска соперника. Отдельная очередь у каждого типа
+// игры, варианта правил и способа подбора
+type Matchmaker struct {
+	queues map[string][]*QueueEntry
+	rating func(playerID, gameType string) float64 // Рейтинг для подбора по рейтингу
+	mutex  sync.Mutex
+}
+
+var matchmaker = &Matchmaker{
+	queues: make(map[string][]*QueueEntry),
+	rating: func(playerID, gameType string) float64 { return ratingBook.get(playerID, gameType).Rating },
+}
+
+// queueKey ключ очереди. Настройки приводятся к каноническому JSON, чтобы
+// порядок полей не разделял одинаковые варианты правил
+func queueKey(gameType, mode string, options json.RawMessage) (string, json.RawMessage, error) {
+	if len(options) == 0 || string(options) == "null" {
+		return gameType + "|" + mode, nil, nil
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(options, &parsed); err != nil {
+		return "", nil, fmt.Errorf("некорректные настройки игры")
+	}
+	canonical, _ := json.Marshal(parsed)
+	return gameType + "|" + mode + "|" + string(canonical), canonical, nil
+}
+
+// enqueue ставит игрока в очередь и возвращает его место в ней; 0 — соперник
+// нашелся сразу. Прежняя заявка игрока отменяется: искать соперника можно
+// только в одной очереди
+func (m *Matchmaker) enqueue(client *Client, data QueueData) (int, error) {
+	if data.GameType == "" {
+		data.GameType = "tictactoe"
+	}
+	engine, exists := engineFor(data.GameType)
+	if !exists {
+		return 0, fmt.Errorf("неверный тип игры")
+	}
+	if _, err := validateOptions(engine, data.Options); err != nil {
+		return 0, err
+	}
+
+	if data.Mode == "" {
+		data.Mode = "fifo"
+	}
+	if data.Mode != "fifo" && data.Mode != "rating" {
+		return 0, fmt.Errorf("неизвестный способ подбора: %s", data.Mode)
+	}
+
+	key, options, err := queueKey(data.GameType, data.Mode, data.Options)
+	if err != nil {
+		return 0, err
+	}
+
+	player := client.session.Player
+	entry := &QueueEntry{
+		PlayerID:   player.ID,
+		PlayerName: player.Name,
+		GameType:   data.GameType,
+		Options:    options,
+		Mode:       data.Mode,
+		Rating:     m.rating(player.ID, data.GameType),
+		Joined:     time.Now(),
+		client:     client,
+	}
+
+	m.mutex.Lock()
+	m.removeLocked(func(e *QueueEntry) bool { return e.PlayerID == player.ID })
+	m.queues[key] = append(m.queues[key], entry)
+	position := len(m.queues[key])
+	pairs := m.matchLocked(key, time.Now())
+	m.mutex.Unlock()
+
+	log.Printf("Игрок %s ищет соперника (%s)", player.Name, key)
+	m.startMatches(pairs)
+	for _, pair := range pairs {
+		if pair[0] == entry || pair[1] == entry {
+			return 0, nil
+		}
+	}
+	return position, nil
+}
+
+// cancel убирает заявку игрока. Возвращает false, если ее не было
+func (m *Matchmaker) cancel(playerID string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return len(m.removeLocked(func(e *QueueEntry) bool { return e.PlayerID == playerID })) > 0
+}
+
+// removeClient убирает заявки закрытого подключения
+func (m *Matchmaker) removeClient(client *Client) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, entry := range m.removeLocked(func(e *QueueEntry) bool { return e.client == client }) {
+		log.Printf("Игрок %s отключился и покинул очередь", entry.PlayerName)
+	}
+}
+
+// removeLocked удаляет из всех очередей подходящие заявки и возвращает их. Вызывается под блокировкой
+func (m *Matchmaker) removeLocked(match func(*QueueEntry) bool) []*QueueEntry {
+	var removed []*QueueEntry
+	for key, queue := range m.queues {
+		kept := queue[:0]
+		for _, entry := range queue {
+			if match(entry) {
+				removed = append(removed, entry)
+			} else {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == 0 {
+			delete(m.queues, key)
+		} else {
+			m.queues[key] = kept
+		}
+	}
+	return removed
+}
+
+// matchLocked составляет пары в очереди key. Первым в паре идет тот, кто
+// ждал дольше. Вызывается под блокировкой
+func (m *Matchmaker) matchLocked(key string, now time.Time) [][2]*QueueEntry {
+	var pairs [][2]*QueueEntry
+	queue := m.queues[key]
+
+	for i := 0; i < len(queue); i++ {
+		first := queue[i]
+		best := -1
+		for j := i + 1; j < len(queue); j++ {
+			if first.Mode == "fifo" {
+				best = j
+				break
+			}
+
+			// Окно растет, пока игрок ждет; пара подходит, если укладывается в окно обоих
+			window := min(ratingWindowFor(first, now), ratingWindowFor(queue[j], now))
+			difference := math.Abs(first.Rating - queue[j].Rating)
+			if difference <= window && (best == -1 || difference < math.Abs(first.Rating-queue[best].Rating)) {
+				best = j
+			}
+		}
+		if best == -1 {
+			continue
+		}
+
+		pairs = append(pairs, [2]*QueueEntry{first, queue[best]})
+		queue = append(queue[:best], queue[best+1:]...)
+		queue = append(queue[:i], queue[i+1:]...)
+		i--
+	}
+
+	if len(queue) == 0 {
+		delete(m.queues, key)
+	} else {
+		m.queues[key] = queue
+	}
+	return pairs
+}
+
+// ratingWindowFor допустимая разница рейтингов для заявки, ждущей с entry.Joined
+func ratingWindowFor(entry *QueueEntry, now time.Time) float64 {
+	return ratingWindow + ratingWindowGrowth*now.Sub(entry.Joined).Seconds()
+}
+
+// errOpponentDisconnected кто-то из пары отключился, пока для нее создавалась игра
+var errOpponentDisconnected = errors.New("соперник отключился")
+
+// startMatches создает игры для составленных пар и сообщает о них игрокам.
+// Если кто-то из пары отключился, оставшийся игрок возвращается в очередь
+func (m *Matchmaker) startMatches(pairs [][2]*QueueEntry) {
+	for _, pair := range pairs {
+		err := startMatch(pair[0], pair[1])
+		if errors.Is(err, errOpponentDisconnected) {
+			for _, entry := range pair {
+				m.requeue(entry)
+			}
+			continue
+		}
+		if err != nil {
+			log.Printf("Ошибка создания игры для %s и %s: %v", pair[0].PlayerName, pair[1].PlayerName, err)
+			for _, entry := range pair {
+				entry.client.sendError(fmt.Errorf("не удалось создать игру: %v", err))
+			}
+		}
+	}
+}
+
+// requeue возвращает заявку в очередь с прежним временем входа: игрок не
+// теряет места из-за отключившегося соперника. Заявки закрытых подключений
+// и игроков, которые уже встали в очередь заново, не возвращаются
+func (m *Matchmaker) requeue(entry *QueueEntry) {
+	key, _, err := queueKey(entry.GameType, entry.Mode, entry.Options)
+	if err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	if entry.client.isClosed() || m.queuedLocked(entry.PlayerID) {
+		m.mutex.Unlock()
+		return
+	}
+	queue := m.queues[key]
+	i := sort.Search(len(queue), func(i int) bool { return queue[i].Joined.After(entry.Joined) })
+	m.queues[key] = slices.Insert(queue, i, entry)
+	pairs := m.matchLocked(key, time.Now())
+	m.mutex.Unlock()
+
+	log.Printf("Соперник игрока %s отключился, игрок возвращен в очередь", entry.PlayerName)
+	m.startMatches(pairs)
+}
+
+// queuedLocked проверяет, есть ли у игрока заявка. Вызывается под блокировкой
+func (m *Matchmaker) queuedLocked(playerID string) bool {
+	for _, queue := range m.queues {
+		for _, entry := range queue {
+			if entry.PlayerID == playerID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// connected проверяет, что подключения обоих игроков пары открыты
+func connected(entries ...*QueueEntry) bool {
+	for _, entry := range entries {
+		if entry.client.isClosed() {
+			return false
+		}
+	}
+	return true
+}
+
+// startMatch создает игру, рассаживает игроков и привязывает их подключения.
+// Если игру не удалось подготовить, недосозданная игра удаляется
+func startMatch(first, second *QueueEntry) error {
+	if !connected(first, second) {
+		return errOpponentDisconnected
+	}
+
+	game, err := gameManager.createGame(first.PlayerID, first.PlayerName, first.GameType, first.Options, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := gameManager.joinGame(game.ID, second.PlayerID, second.PlayerName); err != nil {
+		gameManager.removeGame(game.ID)
+		return err
+	}
+
+	// Игрокам сообщается об игре только после того, как привязаны оба подключения
+	entries := []*QueueEntry{first, second}
+	views := make([]*Game, len(entries))
+	for i, entry := range entries {
+		view, err := gameManager.attachClient(game.ID, entry.client)
+		if err != nil {
+			gameManager.removeGame(game.ID)
+			return err
+		}
+		views[i] = view
+	}
+
+	// Подключение могло закрыться, пока игра создавалась
+	if !connected(first, second) {
+		gameManager.removeGame(game.ID)
+		return errOpponentDisconnected
+	}
+
+	for i, entry := range entries {
+		entry.client.send(Message{
+			Type: "matchFound",
+			Data: views[i],
+		})
+	}
+	return nil
+}
+
+// run периодически снимает просроченные заявки и повторяет подбор по
+// рейтингу: окна расширяются со временем
+func (m *Matchmaker) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		m.mutex.Lock()
+		expired := m.removeLocked(func(e *QueueEntry) bool { return now.Sub(e.Joined) > queueTimeout })
+		var pairs [][2]*QueueEntry
+		for key := range m.queues {
+			pairs = append(pairs, m.matchLocked(key, now)...)
+		}
+		m.mutex.Unlock()
+
+		for _, entry := range expired {
+			log.Printf("Игрок %s не дождался соперника", entry.PlayerName)
+			entry.client.send(Message{
+				Type: "queueLeft",
+				Data: map[string]string{"reason": "timeout"},
+			})
+		}
+		m.startMatches(pairs)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Profile профиль игрока: создается при первом входе через Telegram
+type Profile struct {
+	ID      string                   `json:"id"`
+	Name    string                   `json:"name"` // Имя из Telegram при последнем входе
+	Created time.Time                `json:"created"`
+	Stats   map[string]*ProfileStats `json:"stats"` // Итоги партий по типам игр
+}
+
+// ProfileStats итоги завершенных партий игрока в одном типе игры, включая игры с ботами
+type ProfileStats struct {
+	Games  int `json:"games"`
+	Wins   int `json:"wins"`
+	Losses int `json:"losses"`
+	Draws  int `json:"draws"`
+}
+
+// ProfileStore сохраняет профили между перезапусками сервера
+type ProfileStore interface {
+	SaveProfile(profile *Profile) error
+	LoadProfiles() ([]*Profile, error)
+}
+
+// ProfileBook профили всех игроков
+type ProfileBook struct {
+	profiles map[string]*Profile
+	store    ProfileStore // Постоянное хранилище, nil — только в памяти
+	mutex    sync.RWMutex
+}
+
+var profiles = &ProfileBook{
+	profiles: make(map[string]*Profile),
+}
+
+// load загружает сохраненные профили при запуске сервера
+func (b *ProfileBook) load() error {
+	if b.store == nil {
+		return nil
+	}
+
+	loaded, err := b.store.LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, profile := range loaded {
+		b.profiles[profile.ID] = profile
+	}
+	log.Printf("Загружено профилей из хранилища: %d", len(loaded))
+	return nil
+}
+
+// touch создает профиль при первом входе игрока и обновляет имя при последующих
+func (b *ProfileBook) touch(identity *Identity) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	profile, exists := b.profiles[identity.ID]
+	if exists && profile.Name == identity.Name {
+		return
+	}
+	if !exists {
+		profile = &Profile{
+			ID:      identity.ID,
+			Created: time.Now(),
+			Stats:   make(map[string]*ProfileStats),
+		}
+		b.profiles[identity.ID] = profile
+	}
+	profile.Name = identity.Name
+	b.saveLocked(profile)
+}
+
+// recordGame добавляет итог завершенной партии в профили игроков. Боты профилей не имеют
+func (b *ProfileBook) recordGame(game *Game) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	winner := game.winnerIndex()
+	for i, player := range game.Players {
+		profile, exists := b.profiles[player.ID]
+		if player.Bot != "" || !exists {
+			continue
+		}
+
+		stats := profile.Stats[game.Type]
+		if stats == nil {
+			stats = &ProfileStats{}
+			profile.Stats[game.Type] = stats
+		}
+		stats.Games++
+		switch winner {
+		case -1:
+			stats.Draws++
+		case i:
+			stats.Wins++
+		default:
+			stats.Losses++
+		}
+		b.saveLocked(profile)
+	}
+}
+
+// saveLocked сохраняет профиль в хранилище. Вызывается под блокировкой
+func (b *ProfileBook) saveLocked(profile *Profile) {
+	if b.store == nil {
+		return
+	}
+	if err := b.store.SaveProfile(profile); err != nil {
+		log.Printf("Ошибка сохранения профиля %s: %v", profile.ID, err)
+	}
+}
+
+// get возвращает копию профиля игрока
+func (b *ProfileBook) get(playerID string) (*Profile, bool) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	profile, exists := b.profiles[playerID]
+	if !exists {
+		return nil, false
+	}
+
+	copied := *profile
+	copied.Stats = make(map[string]*ProfileStats, len(profile.Stats))
+	for gameType, stats := range profile.Stats {
+		statsCopy := *stats
+		copied.Stats[gameType] = &statsCopy
+	}
+	return &copied, true
+}
+
+// profileHandler отдает профиль игрока вместе с рейтингами
+func profileHandler(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["id"]
+
+	profile, exists := profiles.get(playerID)
+	if !exists {
+		http.Error(w, "Игрок не найден", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		*Profile
+		Ratings []Rating `json:"ratings"`
+	}{profile, ratingBook.forPlayer(playerID)})
+}
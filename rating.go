@@ -0,0 +1,340 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Параметры Glicko-2. Каждая партия считается отдельным рейтинговым периодом
+const (
+	glickoScale       = 173.7178 // Перевод между шкалой Glicko и внутренней шкалой Glicko-2
+	glickoTau         = 0.5      // Насколько быстро может меняться волатильность
+	glickoEpsilon     = 0.000001 // Точность подбора волатильности
+	defaultRD         = 350.0
+	defaultVolatility = 0.06
+)
+
+// Rating рейтинг игрока в одном типе игры
+type Rating struct {
+	PlayerID   string    `json:"playerId"`
+	GameType   string    `json:"gameType"`
+	Rating     float64   `json:"rating"`
+	RD         float64   `json:"rd"`         // Отклонение рейтинга: чем меньше, тем надежнее оценка
+	Volatility float64   `json:"volatility"` // Насколько неровно играет игрок
+	Games      int       `json:"games"`
+	Wins       int       `json:"wins"`
+	Losses     int       `json:"losses"`
+	Draws      int       `json:"draws"`
+	Updated    time.Time `json:"updated"`
+}
+
+// RatingConfig правила учета партий в рейтинге
+type RatingConfig struct {
+	IncludeBots bool // Учитывать партии с ботами; у бота свой рейтинг на каждый уровень сложности
+	MinMoves    int  // Сколько действий должен сделать каждый игрок, иначе партия считается брошенной
+}
+
+// RatingStore сохраняет рейтинги между перезапусками сервера
+type RatingStore interface {
+	SaveRating(rating *Rating) error
+	LoadRatings() ([]*Rating, error)
+}
+
+// RatingBook рейтинги всех игроков по типам игр
+type RatingBook struct {
+	ratings map[string]*Rating // Ключ — ratingKey
+	store   RatingStore        // Постоянное хранилище, nil — только в памяти
+	config  RatingConfig
+	mutex   sync.RWMutex
+}
+
+var ratingBook = &RatingBook{
+	ratings: make(map[string]*Rating),
+	config:  loadRatingConfig(),
+}
+
+// loadRatingConfig читает правила учета партий из окружения
+func loadRatingConfig() RatingConfig {
+	config := RatingConfig{MinMoves: 1}
+
+	switch os.Getenv("RATING_INCLUDE_BOTS") {
+	case "1", "true", "yes":
+		config.IncludeBots = true
+	}
+
+	if value := os.Getenv("RATING_MIN_MOVES"); value != "" {
+		if minMoves, err := strconv.Atoi(value); err == nil && minMoves >= 0 {
+			config.MinMoves = minMoves
+		}
+	}
+
+	return config
+}
+
+func ratingKey(playerID, gameType string) string {
+	return gameType + "|" + playerID
+}
+
+// newRating начальный рейтинг игрока, который еще не играл
+func newRating(playerID, gameType string) *Rating {
+	return &Rating{
+		PlayerID:   playerID,
+		GameType:   gameType,
+		Rating:     defaultRating,
+		RD:         defaultRD,
+		Volatility: defaultVolatility,
+	}
+}
+
+// load загружает сохраненные рейтинги при запуске сервера
+func (b *RatingBook) load() error {
+	if b.store == nil {
+		return nil
+	}
+
+	ratings, err := b.store.LoadRatings()
+	if err != nil {
+		return err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for _, rating := range ratings {
+		b.ratings[ratingKey(rating.PlayerID, rating.GameType)] = rating
+	}
+	log.Printf("Загружено рейтингов из хранилища: %d", len(ratings))
+	return nil
+}
+
+// get возвращает копию рейтинга игрока в типе игры
+func (b *RatingBook) get(playerID, gameType string) Rating {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if rating, exists := b.ratings[ratingKey(playerID, gameType)]; exists {
+		return *rating
+	}
+	return *newRating(playerID, gameType)
+}
+
+// forPlayer возвращает рейтинги игрока во всех играх, где он играл
+func (b *RatingBook) forPlayer(playerID string) []Rating {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	ratings := []Rating{}
+	for _, rating := range b.ratings {
+		if rating.PlayerID == playerID {
+			ratings = append(ratings, *rating)
+		}
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		return ratings[i].GameType < ratings[j].GameType
+	})
+	return ratings
+}
+
+// ratingPlayerID под каким ID игрок учитывается в рейтинге: у ботов ID
+// меняется от партии к партии, поэтому рейтинг ведется по уровню сложности
+func ratingPlayerID(player Player) string {
+	if player.Bot != "" {
+		return "bot_" + player.Bot
+	}
+	return player.ID
+}
+
+// winnerIndex возвращает индекс победителя или -1 при ничьей
+func (g *Game) winnerIndex() int {
+	switch g.Winner {
+	case "player1":
+		return 0
+	case "player2":
+		return 1
+	}
+	for i, player := range g.Players {
+		if player.Symbol == g.Winner {
+			return i
+		}
+	}
+	return -1
+}
+
+// rated проверяет, учитывается ли завершенная партия в рейтинге
+func (b *RatingBook) rated(game *Game) bool {
+	if len(game.Players) != 2 {
+		return false
+	}
+
+	moves := make([]int, len(game.Players))
+	for _, event := range game.Events {
+		moves[event.Player]++
+	}
+	for i, player := range game.Players {
+		if player.Bot != "" && !b.config.IncludeBots {
+			return false
+		}
+		if moves[i] < b.config.MinMoves {
+			return false
+		}
+	}
+	return true
+}
+
+// recordGame обновляет рейтинги игроков завершенной партии. Вызывается
+// один раз, когда партия переходит в "finished"
+func (b *RatingBook) recordGame(game *Game) {
+	if !b.rated(game) {
+		return
+	}
+
+	scores := [2]float64{0.5, 0.5}
+	switch game.winnerIndex() {
+	case 0:
+		scores = [2]float64{1, 0}
+	case 1:
+		scores = [2]float64{0, 1}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// Оба рейтинга пересчитываются от значений до партии
+	var before [2]Rating
+	for i, player := range game.Players {
+		before[i] = *b.lookupLocked(ratingPlayerID(player), game.Type)
+	}
+
+	now := time.Now()
+	for i := range game.Players {
+		rating := b.lookupLocked(before[i].PlayerID, game.Type)
+		rating.Rating, rating.RD, rating.Volatility = glicko2(before[i], before[1-i], scores[i])
+		rating.Games++
+		switch scores[i] {
+		case 1:
+			rating.Wins++
+		case 0:
+			rating.Losses++
+		default:
+			rating.Draws++
+		}
+		rating.Updated = now
+
+		if b.store != nil {
+			if err := b.store.SaveRating(rating); err != nil {
+				log.Printf("Ошибка сохранения рейтинга игрока %s: %v", rating.PlayerID, err)
+			}
+		}
+	}
+
+	playerIDs := [2]string{before[0].PlayerID, before[1].PlayerID}
+	after := [2]float64{b.lookupLocked(playerIDs[0], game.Type).Rating, b.lookupLocked(playerIDs[1], game.Type).Rating}
+	leaderboards.record(game, playerIDs, scores, after, now)
+
+	log.Printf("Рейтинги после игры %s (%s): %s %.0f → %.0f, %s %.0f → %.0f", game.ID, game.Type,
+		playerIDs[0], before[0].Rating, after[0], playerIDs[1], before[1].Rating, after[1])
+}
+
+// lookupLocked возвращает рейтинг, создавая начальный. Вызывается под блокировкой
+func (b *RatingBook) lookupLocked(playerID, gameType string) *Rating {
+	key := ratingKey(playerID, gameType)
+	rating, exists := b.ratings[key]
+	if !exists {
+		rating = newRating(playerID, gameType)
+		b.ratings[key] = rating
+	}
+	return rating
+}
+
+// glicko2 пересчитывает рейтинг игрока после одной партии против opponent,
+// score — 1, 0.5 или 0. Возвращает рейтинг, отклонение и волатильность
+func glicko2(player, opponent Rating, score float64) (float64, float64, float64) {
+	return glicko2Period(player, []Rating{opponent}, []float64{score})
+}
+
+// glicko2Period пересчитывает рейтинг игрока по всем партиям рейтингового
+// периода: scores[i] — результат партии против opponents[i]
+func glicko2Period(player Rating, opponents []Rating, scores []float64) (float64, float64, float64) {
+	mu := (player.Rating - defaultRating) / glickoScale
+	phi := player.RD / glickoScale
+
+	var variance, improvement float64
+	for i, opponent := range opponents {
+		opponentMu := (opponent.Rating - defaultRating) / glickoScale
+		opponentPhi := opponent.RD / glickoScale
+
+		g := 1 / math.Sqrt(1+3*opponentPhi*opponentPhi/(math.Pi*math.Pi))
+		expected := 1 / (1 + math.Exp(-g*(mu-opponentMu)))
+		variance += g * g * expected * (1 - expected)
+		improvement += g * (scores[i] - expected)
+	}
+	v := 1 / variance
+	delta := v * improvement
+
+	// Новая волатильность: корень f(x) = 0 методом Иллинойса
+	a := math.Log(player.Volatility * player.Volatility)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		return ex*(delta*delta-phi*phi-v-ex)/(2*math.Pow(phi*phi+v+ex, 2)) - (x-a)/(glickoTau*glickoTau)
+	}
+
+	low := a
+	var high float64
+	if delta*delta > phi*phi+v {
+		high = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*glickoTau) < 0 {
+			k++
+		}
+		high = a - k*glickoTau
+	}
+
+	fLow, fHigh := f(low), f(high)
+	for math.Abs(high-low) > glickoEpsilon {
+		middle := low + (low-high)*fLow/(fHigh-fLow)
+		fMiddle := f(middle)
+		if fMiddle*fHigh <= 0 {
+			low, fLow = high, fHigh
+		} else {
+			fLow /= 2
+		}
+		high, fHigh = middle, fMiddle
+	}
+	volatility := math.Exp(low / 2)
+
+	phiStar := math.Sqrt(phi*phi + volatility*volatility)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	newMu := mu + newPhi*newPhi*improvement
+
+	return glickoScale*newMu + defaultRating, glickoScale * newPhi, volatility
+}
+
+// ratingHandler отдает рейтинги игрока: во всех играх или, с ?gameType=, в одной
+func ratingHandler(w http.ResponseWriter, r *http.Request) {
+	playerID := mux.Vars(r)["id"]
+
+	w.Header().Set("Content-Type", "application/json")
+	if gameType := r.URL.Query().Get("gameType"); gameType != "" {
+		if _, exists := engineFor(gameType); !exists {
+			http.Error(w, "Неверный тип игры", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(ratingBook.get(playerID, gameType))
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"playerId": playerID,
+		"ratings":  ratingBook.forPlayer(playerID),
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func assertClose(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %.6f, ожидалось %.6f ± %g", name, got, want, tolerance)
+	}
+}
+
+// Пример из описания Glicko-2 (Glickman, «Example of the Glicko-2 system»):
+// игрок 1500/200 выигрывает у 1400/30 и проигрывает 1550/100 и 1700/300
+func TestGlicko2GlickmanExample(t *testing.T) {
+	player := Rating{Rating: 1500, RD: 200, Volatility: 0.06}
+	opponents := []Rating{
+		{Rating: 1400, RD: 30},
+		{Rating: 1550, RD: 100},
+		{Rating: 1700, RD: 300},
+	}
+
+	rating, rd, volatility := glicko2Period(player, opponents, []float64{1, 0, 0})
+	assertClose(t, "рейтинг", rating, 1464.06, 0.01)
+	assertClose(t, "отклонение", rd, 151.52, 0.01)
+	assertClose(t, "волатильность", volatility, 0.05999, 0.00001)
+}
+
+func TestGlicko2SingleGame(t *testing.T) {
+	// Первая партия двух новых игроков: значения посчитаны по формулам Glicko-2 вручную
+	newcomer := *newRating("a", "chess")
+
+	rating, rd, volatility := glicko2(newcomer, newcomer, 1)
+	assertClose(t, "рейтинг победителя", rating, 1662.31, 0.01)
+	assertClose(t, "отклонение победителя", rd, 290.32, 0.01)
+	assertClose(t, "волатильность победителя", volatility, 0.06, 0.0001)
+
+	rating, rd, _ = glicko2(newcomer, newcomer, 0)
+	assertClose(t, "рейтинг проигравшего", rating, 1337.69, 0.01)
+	assertClose(t, "отклонение проигравшего", rd, 290.32, 0.01)
+
+	rating, _, _ = glicko2(newcomer, newcomer, 0.5)
+	assertClose(t, "рейтинг при ничьей", rating, 1500, 0.000001)
+}
+
+func TestGlicko2VolatilityGrowsOnUpset(t *testing.T) {
+	// Надежно оцененный игрок неожиданно обыгрывает намного более сильного:
+	// результат далек от ожидаемого, и волатильность растет
+	player := Rating{Rating: 1500, RD: 50, Volatility: 0.06}
+	opponent := Rating{Rating: 2300, RD: 50, Volatility: 0.06}
+
+	rating, rd, volatility := glicko2(player, opponent, 1)
+	if volatility <= player.Volatility {
+		t.Errorf("волатильность %.6f не выросла после неожиданной победы", volatility)
+	}
+	if rating <= player.Rating {
+		t.Errorf("рейтинг %.2f не вырос после победы", rating)
+	}
+	if math.IsNaN(rd) || rd <= 0 || rd > defaultRD {
+		t.Errorf("отклонение %.2f вне допустимых значений", rd)
+	}
+
+	// Ожидаемая победа почти не меняет волатильность
+	_, _, volatility = glicko2(opponent, player, 1)
+	assertClose(t, "волатильность после ожидаемой победы", volatility, 0.06, 0.0001)
+}
+
+// ratedGame создает завершенную партию, в которой игроки сделали moves действий
+func ratedGame(moves [2]int, bot string) *Game {
+	game := &Game{
+		Type:    "tictactoe",
+		Players: []Player{{ID: "a", Symbol: "X"}, {ID: "b", Symbol: "O", Bot: bot}},
+		Status:  "finished",
+		Winner:  "X",
+	}
+	for player, count := range moves {
+		for i := 0; i < count; i++ {
+			game.Events = append(game.Events, GameEvent{Seq: len(game.Events) + 1, Player: player, Action: "move"})
+		}
+	}
+	return game
+}
+
+func TestRatingBookRated(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RatingConfig
+		game   *Game
+		want   bool
+	}{
+		{"обычная партия", RatingConfig{MinMoves: 1}, ratedGame([2]int{3, 2}, ""), true},
+		{"партия с ботом", RatingConfig{MinMoves: 1}, ratedGame([2]int{3, 2}, "medium"), false},
+		{"партия с ботом, боты учитываются", RatingConfig{IncludeBots: true, MinMoves: 1}, ratedGame([2]int{3, 2}, "medium"), true},
+		{"соперник не сделал ни хода", RatingConfig{MinMoves: 1}, ratedGame([2]int{1, 0}, ""), false},
+		{"меньше MinMoves", RatingConfig{MinMoves: 3}, ratedGame([2]int{3, 2}, ""), false},
+		{"ровно MinMoves", RatingConfig{MinMoves: 2}, ratedGame([2]int{3, 2}, ""), true},
+		{"без ограничения ходов", RatingConfig{MinMoves: 0}, ratedGame([2]int{0, 0}, ""), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			book := &RatingBook{ratings: make(map[string]*Rating), config: tt.config}
+			if got := book.rated(tt.game); got != tt.want {
+				t.Errorf("rated() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+
+	single := ratedGame([2]int{1, 1}, "")
+	single.Players = single.Players[:1]
+	single.Events = single.Events[:1]
+	book := &RatingBook{ratings: make(map[string]*Rating), config: RatingConfig{}}
+	if book.rated(single) {
+		t.Error("rated() учел партию с одним игроком")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const reversiSize = 8
+
+// ReversiState состояние партии в реверси. Черные (игрок 0) ходят первыми
+type ReversiState struct {
+	Board      [][]string     `json:"board"`                // Board[row][col]: "", "black" или "white"
+	LastMove   *[2]int        `json:"lastMove,omitempty"`   // Последний ход (row, col)
+	Flipped    [][2]int       `json:"flipped,omitempty"`    // Фишки, перевернутые последним ходом
+	Passed     string         `json:"passed,omitempty"`     // Кто пропустил ход, потому что ходить было некуда
+	Score      map[string]int `json:"score,omitempty"`      // Число фишек каждого цвета; только в представлении
+	LegalMoves [][2]int       `json:"legalMoves,omitempty"` // Ходы стороны, чья очередь; только в представлении
+}
+
+// ReversiEngine правила реверси
+type ReversiEngine struct{}
+
+func init() {
+	registerEngine("reversi", ReversiEngine{})
+}
+
+func (e ReversiEngine) NewState(options json.RawMessage) (interface{}, error) {
+	board := newGrid(reversiSize)
+	middle := reversiSize / 2
+	board[middle-1][middle-1], board[middle][middle] = e.Symbol(1), e.Symbol(1)
+	board[middle-1][middle], board[middle][middle-1] = e.Symbol(0), e.Symbol(0)
+	return &ReversiState{Board: board}, nil
+}
+
+func (ReversiEngine) Symbol(playerIndex int) string {
+	if playerIndex == 0 {
+		return "black"
+	}
+	return "white"
+}
+
+func (ReversiEngine) StartStatus() string {
+	return "playing"
+}
+
+func (e ReversiEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	if action != "move" {
+		return fmt.Errorf("неизвестное действие")
+	}
+
+	var move MoveData
+	if err := json.Unmarshal(data, &move); err != nil {
+		return fmt.Errorf("некорректный ход")
+	}
+	return e.makeMove(game, playerIndex, move.Row, move.Col)
+}
+
+// makeMove ставит фишку и переворачивает захваченные. Если сопернику
+// ходить некуда, он пропускает ход
+func (e ReversiEngine) makeMove(game *Game, playerIndex, row, col int) error {
+	state := game.State.(*ReversiState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	if row < 0 || row >= reversiSize || col < 0 || col >= reversiSize {
+		return fmt.Errorf("неверная позиция")
+	}
+	if state.Board[row][col] != "" {
+		return fmt.Errorf("позиция уже занята")
+	}
+
+	symbol := e.Symbol(playerIndex)
+	flipped := reversiFlips(state.Board, symbol, row, col)
+	if len(flipped) == 0 {
+		return fmt.Errorf("ход должен перевернуть хотя бы одну фишку соперника")
+	}
+
+	state.Board[row][col] = symbol
+	for _, cell := range flipped {
+		state.Board[cell[0]][cell[1]] = symbol
+	}
+	state.LastMove = &[2]int{row, col}
+	state.Flipped = flipped
+	state.Passed = ""
+
+	opponent := 1 - playerIndex
+	switch {
+	case len(reversiMoves(state.Board, e.Symbol(opponent))) > 0:
+		game.Turn = opponent
+	case len(reversiMoves(state.Board, symbol)) > 0:
+		state.Passed = e.Symbol(opponent)
+	}
+	return nil
+}
+
+// Outcome: партия заканчивается, когда ходить не может ни одна сторона;
+// побеждает та, у кого больше фишек
+func (e ReversiEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*ReversiState)
+	if len(reversiMoves(state.Board, e.Symbol(0))) > 0 || len(reversiMoves(state.Board, e.Symbol(1))) > 0 {
+		return false, ""
+	}
+
+	score := reversiScore(state.Board)
+	switch {
+	case score[e.Symbol(0)] > score[e.Symbol(1)]:
+		return true, e.Symbol(0)
+	case score[e.Symbol(1)] > score[e.Symbol(0)]:
+		return true, e.Symbol(1)
+	}
+	return true, "draw"
+}
+
+func (e ReversiEngine) View(game *Game, playerIndex int) interface{} {
+	state := *game.State.(*ReversiState)
+	state.Board = copyGrid(state.Board)
+	state.Flipped = append([][2]int{}, state.Flipped...)
+	state.Score = reversiScore(state.Board)
+	if game.Status == "playing" {
+		state.LegalMoves = reversiMoves(state.Board, e.Symbol(game.Turn))
+	}
+	return &state
+}
+
+func (e ReversiEngine) Reset(game *Game) {
+	game.State, _ = e.NewState(game.Options)
+}
+
+// reversiFlips возвращает фишки соперника, которые перевернет ход symbol в
+// (row, col): во всех восьми направлениях до ближайшей своей фишки
+func reversiFlips(board [][]string, symbol string, row, col int) [][2]int {
+	var flipped [][2]int
+	for _, direction := range kingSteps {
+		var line [][2]int
+		r, c := row+direction[1], col+direction[0]
+		for r >= 0 && r < reversiSize && c >= 0 && c < reversiSize && board[r][c] != "" && board[r][c] != symbol {
+			line = append(line, [2]int{r, c})
+			r, c = r+direction[1], c+direction[0]
+		}
+		if len(line) > 0 && r >= 0 && r < reversiSize && c >= 0 && c < reversiSize && board[r][c] == symbol {
+			flipped = append(flipped, line...)
+		}
+	}
+	return flipped
+}
+
+// reversiMoves возвращает свободные клетки, ход в которые что-то переворачивает
+func reversiMoves(board [][]string, symbol string) [][2]int {
+	var moves [][2]int
+	for row := range board {
+		for col, cell := range board[row] {
+			if cell == "" && len(reversiFlips(board, symbol, row, col)) > 0 {
+				moves = append(moves, [2]int{row, col})
+			}
+		}
+	}
+	return moves
+}
+
+// reversiScore считает фишки каждого цвета
+func reversiScore(board [][]string) map[string]int {
+	score := map[string]int{"black": 0, "white": 0}
+	for _, row := range board {
+		for _, cell := range row {
+			if cell != "" {
+				score[cell]++
+			}
+		}
+	}
+	return score
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// Series серия партий «до большинства побед» из BestOf. Партии серии
+// продолжаются через голосование за повтор, первый ход переходит от
+// партии к партии, а серия завершается, как только исход предрешен
+type Series struct {
+	BestOf int            `json:"bestOf"`           // 3, 5 или 7
+	Score  map[string]int `json:"score"`            // Победы в партиях по ID игроков
+	Draws  int            `json:"draws"`            // Партии серии, сыгранные вничью
+	Played int            `json:"played"`           // Завершенных партий
+	Winner string         `json:"winner,omitempty"` // ID победителя серии или "draw"; пусто, пока серия идет
+}
+
+// newSeries создает серию из bestOf партий. 0 и 1 — обычная игра без серии
+func newSeries(bestOf int) (*Series, error) {
+	switch bestOf {
+	case 0, 1:
+		return nil, nil
+	case 3, 5, 7:
+		return &Series{BestOf: bestOf, Score: make(map[string]int)}, nil
+	}
+	return nil, fmt.Errorf("серия может быть из 3, 5 или 7 партий")
+}
+
+// finished проверяет, завершена ли серия
+func (s *Series) finished() bool {
+	return s != nil && s.Winner != ""
+}
+
+// copy возвращает независимую копию серии для отправки клиентам
+func (s *Series) copy() *Series {
+	if s == nil {
+		return nil
+	}
+	copied := *s
+	copied.Score = make(map[string]int, len(s.Score))
+	for playerID, wins := range s.Score {
+		copied.Score[playerID] = wins
+	}
+	return &copied
+}
+
+// record засчитывает только что завершенную партию и подводит итог серии,
+// если отстающему уже не догнать лидера в оставшихся партиях. Вызывается
+// под блокировкой игры
+func (s *Series) record(game *Game) {
+	if s == nil || s.finished() || len(game.Players) != 2 {
+		return
+	}
+
+	s.Played++
+	if winner := game.winnerIndex(); winner == -1 {
+		s.Draws++
+	} else {
+		s.Score[game.Players[winner].ID]++
+	}
+
+	first, second := game.Players[0].ID, game.Players[1].ID
+	remaining := s.BestOf - s.Played
+	switch {
+	case s.Score[first] > s.Score[second]+remaining:
+		s.Winner = first
+	case s.Score[second] > s.Score[first]+remaining:
+		s.Winner = second
+	case remaining == 0:
+		s.Winner = "draw"
+	default:
+		return
+	}
+	log.Printf("Серия в игре %s завершена после %d партий, победитель: %s", game.ID, s.Played, s.Winner)
+}
+
+// rotateSeats меняет игроков местами перед следующей партией серии. Первым
+// ходит игрок с индексом 0, а в шахматах и шашках первым ходит цвет, а не
+// место, поэтому вместе с местом переходят и символы
+func (g *Game) rotateSeats(engine GameEngine) {
+	if len(g.Players) != 2 {
+		return
+	}
+	g.Players[0], g.Players[1] = g.Players[1], g.Players[0]
+	for i := range g.Players {
+		g.Players[i].Symbol = engine.Symbol(i)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// sessionTTL время жизни токена сессии
+const sessionTTL = 24 * time.Hour
+
+// Session связывает токен с подтвержденной личностью игрока
+type Session struct {
+	Token   string
+	Player  Identity
+	Created time.Time
+}
+
+// SessionStore хранит выданные токены сессий
+type SessionStore struct {
+	sessions map[string]*Session
+	mutex    sync.RWMutex
+}
+
+// Client — WebSocket-подключение, привязанное к сессии игрока
+type Client struct {
+	conn    *websocket.Conn
+	session *Session
+	games   map[string]bool // Игры, к которым присоединилось подключение
+	mutex   sync.Mutex      // WebSocket не допускает параллельной записи
+
+	// Подбор соперника присоединяет подключение к игре из чужой горутины
+	gamesMutex sync.RWMutex
+
+	// Подключение закрыто: подбор соперника не должен сажать его в новую игру
+	closed atomic.Bool
+}
+
+var sessionStore = &SessionStore{
+	sessions: make(map[string]*Session),
+}
+
+// generateToken создает случайный токен сессии
+func generateToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// issue выдает новый токен для игрока
+func (s *SessionStore) issue(identity *Identity) *Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session := &Session{
+		Token:   generateToken(),
+		Player:  *identity,
+		Created: time.Now(),
+	}
+	s.sessions[session.Token] = session
+	return session
+}
+
+// lookup находит действующую сессию по токену
+func (s *SessionStore) lookup(token string) (*Session, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	session, exists := s.sessions[token]
+	if !exists || time.Since(session.Created) > sessionTTL {
+		return nil, false
+	}
+	return session, true
+}
+
+// cleanup удаляет просроченные сессии
+func (s *SessionStore) cleanup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for token, session := range s.sessions {
+		if time.Since(session.Created) > sessionTTL {
+			delete(s.sessions, token)
+		}
+	}
+}
+
+// newClient оборачивает WebSocket-подключение
+func newClient(conn *websocket.Conn) *Client {
+	return &Client{
+		conn:  conn,
+		games: make(map[string]bool),
+	}
+}
+
+// markClosed отмечает, что подключение закрыто
+func (c *Client) markClosed() {
+	c.closed.Store(true)
+}
+
+// isClosed проверяет, закрыто ли подключение
+func (c *Client) isClosed() bool {
+	return c.closed.Load()
+}
+
+// send отправляет сообщение в подключение
+func (c *Client) send(message Message) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.conn.WriteJSON(message)
+}
+
+// detailedError — ошибка с машиночитаемыми подробностями для клиента
+type detailedError interface {
+	error
+	Details() map[string]interface{}
+}
+
+// sendError отправляет клиенту сообщение об ошибке
+func (c *Client) sendError(err error) {
+	data := map[string]interface{}{"message": err.Error()}
+
+	var detailed detailedError
+	if errors.As(err, &detailed) {
+		for key, value := range detailed.Details() {
+			data[key] = value
+		}
+	}
+
+	c.send(Message{
+		Type: "error",
+		Data: data,
+	})
+}
+
+// authorize проверяет, что подключение может действовать в игре от имени игрока
+func (c *Client) authorize(gameID, claimedID string) error {
+	if err := checkPlayerID(&c.session.Player, claimedID); err != nil {
+		return err
+	}
+	if !c.joined(gameID) {
+		return fmt.Errorf("подключение не присоединено к игре")
+	}
+	return nil
+}
+
+// addGame отмечает, что подключение присоединено к игре
+func (c *Client) addGame(gameID string) {
+	c.gamesMutex.Lock()
+	defer c.gamesMutex.Unlock()
+	c.games[gameID] = true
+}
+
+// joined проверяет, присоединено ли подключение к игре
+func (c *Client) joined(gameID string) bool {
+	c.gamesMutex.RLock()
+	defer c.gamesMutex.RUnlock()
+	return c.games[gameID]
+}
+
+// joinedGames возвращает игры, к которым присоединено подключение
+func (c *Client) joinedGames() []string {
+	c.gamesMutex.RLock()
+	defer c.gamesMutex.RUnlock()
+
+	gameIDs := make([]string, 0, len(c.games))
+	for gameID := range c.games {
+		gameIDs = append(gameIDs, gameID)
+	}
+	return gameIDs
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// GameStore сохраняет игры между перезапусками сервера
+type GameStore interface {
+	Save(game *Game) error
+	Delete(gameID string) error
+	LoadAll() ([]*Game, error)
+	Close() error
+}
+
+// BatchStore хранилище, которое умеет объединять записи в одну транзакцию
+type BatchStore interface {
+	Batch(fn func()) error
+}
+
+// BoltStore хранит игры во встроенной базе BoltDB (один файл, без внешних сервисов)
+type BoltStore struct {
+	db *bolt.DB
+
+	// Записи открытого пакета ждут общей транзакции. Запись выполняется под
+	// mutex, чтобы транзакции ложились в базу в том же порядке, что и вызовы
+	mutex    sync.Mutex
+	batching int
+	pending  []func(tx *bolt.Tx) error
+}
+
+var (
+	gamesBucket       = []byte("games")
+	ratingsBucket     = []byte("ratings")
+	leaderboardBucket = []byte("leaderboard")
+	profilesBucket    = []byte("profiles")
+	matchesBucket     = []byte("matches")
+)
+
+// getDBPath возвращает путь к файлу базы данных
+func getDBPath() string {
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = "games.db"
+	}
+	return path
+}
+
+// openBoltStore открывает или создает файл базы данных
+func openBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{gamesBucket, ratingsBucket, leaderboardBucket, profilesBucket, matchesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// update выполняет запись в отдельной транзакции или, если открыт пакет,
+// откладывает ее до его завершения
+func (s *BoltStore) update(write func(tx *bolt.Tx) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.batching > 0 {
+		s.pending = append(s.pending, write)
+		return nil
+	}
+	return s.db.Update(write)
+}
+
+// Batch выполняет записи, сделанные внутри fn, одной транзакцией: каждая
+// транзакция BoltDB — это fsync, а завершение партии меняет игру, рейтинги,
+// таблицы лидеров, профили и архив. Записи других горутин, сделанные в это
+// время, попадают в ту же транзакцию
+func (s *BoltStore) Batch(fn func()) error {
+	s.mutex.Lock()
+	s.batching++
+	s.mutex.Unlock()
+
+	fn()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.batching--
+	writes := s.pending
+	s.pending = nil
+	if len(writes) == 0 {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		for _, write := range writes {
+			if err := write(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) Save(game *Game) error {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(gamesBucket).Put([]byte(game.ID), data)
+	})
+}
+
+func (s *BoltStore) Delete(gameID string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(gamesBucket).Delete([]byte(gameID))
+	})
+}
+
+func (s *BoltStore) LoadAll() ([]*Game, error) {
+	var games []*Game
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(gamesBucket).ForEach(func(key, data []byte) error {
+			var game Game
+			if err := json.Unmarshal(data, &game); err != nil {
+				return fmt.Errorf("игра %s: %w", key, err)
+			}
+			games = append(games, &game)
+			return nil
+		})
+	})
+	return games, err
+}
+
+func (s *BoltStore) SaveRating(rating *Rating) error {
+	data, err := json.Marshal(rating)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(ratingsBucket).Put([]byte(ratingKey(rating.PlayerID, rating.GameType)), data)
+	})
+}
+
+func (s *BoltStore) LoadRatings() ([]*Rating, error) {
+	var ratings []*Rating
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(ratingsBucket).ForEach(func(key, data []byte) error {
+			var rating Rating
+			if err := json.Unmarshal(data, &rating); err != nil {
+				return fmt.Errorf("рейтинг %s: %w", key, err)
+			}
+			ratings = append(ratings, &rating)
+			return nil
+		})
+	})
+	return ratings, err
+}
+
+// leaderboardRecordKey ключ строки таблицы лидеров: "тип|период|игрок"
+func leaderboardRecordKey(gameType, period, playerID string) []byte {
+	return []byte(leaderboardKey(gameType, period) + "|" + playerID)
+}
+
+func (s *BoltStore) SaveLeaderboardRecord(record *LeaderboardRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(leaderboardBucket).Put(leaderboardRecordKey(record.GameType, record.Period, record.Entry.PlayerID), data)
+	})
+}
+
+func (s *BoltStore) DeleteLeaderboardPeriod(gameType, period string) error {
+	prefix := leaderboardRecordKey(gameType, period, "")
+	return s.update(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(leaderboardBucket).Cursor()
+		for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Seek(prefix) {
+			if err := cursor.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) LoadLeaderboardRecords() ([]*LeaderboardRecord, error) {
+	var records []*LeaderboardRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(leaderboardBucket).ForEach(func(key, data []byte) error {
+			var record LeaderboardRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				return fmt.Errorf("строка таблицы лидеров %s: %w", key, err)
+			}
+			records = append(records, &record)
+			return nil
+		})
+	})
+	return records, err
+}
+
+func (s *BoltStore) SaveProfile(profile *Profile) error {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(profilesBucket).Put([]byte(profile.ID), data)
+	})
+}
+
+func (s *BoltStore) LoadProfiles() ([]*Profile, error) {
+	var profiles []*Profile
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(profilesBucket).ForEach(func(key, data []byte) error {
+			var profile Profile
+			if err := json.Unmarshal(data, &profile); err != nil {
+				return fmt.Errorf("профиль %s: %w", key, err)
+			}
+			if profile.Stats == nil {
+				profile.Stats = make(map[string]*ProfileStats)
+			}
+			profiles = append(profiles, &profile)
+			return nil
+		})
+	})
+	return profiles, err
+}
+
+func (s *BoltStore) SaveMatch(match *MatchRecord) error {
+	data, err := json.Marshal(match)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(matchesBucket).Put([]byte(match.ID), data)
+	})
+}
+
+func (s *BoltStore) LoadMatches() ([]*MatchRecord, error) {
+	var matches []*MatchRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(matchesBucket).ForEach(func(key, data []byte) error {
+			var match MatchRecord
+			if err := json.Unmarshal(data, &match); err != nil {
+				return fmt.Errorf("партия %s: %w", key, err)
+			}
+			matches = append(matches, &match)
+			return nil
+		})
+	})
+	return matches, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// UnmarshalJSON восстанавливает игру, создавая состояние через движок ее типа
+func (g *Game) UnmarshalJSON(data []byte) error {
+	type plainGame Game
+	raw := struct {
+		*plainGame
+		State json.RawMessage `json:"state"`
+	}{plainGame: (*plainGame)(g)}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	engine, exists := engineFor(g.Type)
+	if !exists {
+		return fmt.Errorf("неизвестный тип игры %q", g.Type)
+	}
+
+	state, err := engine.NewState(g.Options)
+	if err != nil {
+		return err
+	}
+	g.State = state
+	if len(raw.State) > 0 {
+		return json.Unmarshal(raw.State, g.State)
+	}
+	return nil
+}
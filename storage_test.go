@@ -0,0 +1,62 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *BoltStore {
+	t.Helper()
+	store, err := openBoltStore(filepath.Join(t.TempDir(), "games.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestBoltStoreBatch(t *testing.T) {
+	store := openTestStore(t)
+	rating := newRating("a", "chess")
+	match := &MatchRecord{ID: "GAME01-1", GameID: "GAME01", Type: "chess"}
+
+	err := store.Batch(func() {
+		if err := store.SaveRating(rating); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.SaveMatch(match); err != nil {
+			t.Fatal(err)
+		}
+
+		// До конца пакета записи в базу не попадают
+		if ratings, _ := store.LoadRatings(); len(ratings) != 0 {
+			t.Errorf("рейтинг сохранен до конца пакета: %v", ratings)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ratings, err := store.LoadRatings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ratings) != 1 || ratings[0].PlayerID != "a" {
+		t.Errorf("LoadRatings() = %v, ожидался рейтинг игрока a", ratings)
+	}
+	matches, err := store.LoadMatches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].ID != match.ID {
+		t.Errorf("LoadMatches() = %v, ожидалась партия %s", matches, match.ID)
+	}
+
+	// Вне пакета запись выполняется сразу
+	if err := store.SaveRating(newRating("b", "chess")); err != nil {
+		t.Fatal(err)
+	}
+	if ratings, _ := store.LoadRatings(); len(ratings) != 2 {
+		t.Errorf("LoadRatings() = %v, ожидалось два рейтинга", ratings)
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TicTacToeState состояние игры в крестики-нолики на поле Size x Size
+type TicTacToeState struct {
+	Size      int        `json:"size"`               // Сторона поля, от 3 до 19
+	WinLength int        `json:"winLength"`          // Сколько символов в ряд нужно для победы
+	Board     [][]string `json:"board"`              // Board[row][col]
+	LastMove  *[2]int    `json:"lastMove,omitempty"` // Последний ход (row, col)
+}
+
+// TicTacToeOptions настройки партии в крестики-нолики
+type TicTacToeOptions struct {
+	Size      int `json:"size"`      // По умолчанию 3
+	WinLength int `json:"winLength"` // По умолчанию min(Size, 5)
+}
+
+// MoveData для передачи хода в крестики-нолики
+type MoveData struct {
+	GameID   string `json:"gameId"`
+	Row      int    `json:"row"`
+	Col      int    `json:"col"`
+	Position *int   `json:"position,omitempty"` // Устаревший формат: номер клетки по строкам, row*size+col
+	PlayerID string `json:"playerId"`
+}
+
+const (
+	minTicTacToeSize = 3
+	maxTicTacToeSize = 19
+)
+
+// TicTacToeEngine правила крестиков-ноликов
+type TicTacToeEngine struct{}
+
+func init() {
+	registerEngine("tictactoe", TicTacToeEngine{})
+}
+
+func (TicTacToeEngine) NewState(options json.RawMessage) (interface{}, error) {
+	var opts TicTacToeOptions
+	if len(options) > 0 {
+		if err := json.Unmarshal(options, &opts); err != nil {
+			return nil, fmt.Errorf("некорректные настройки игры")
+		}
+	}
+
+	if opts.Size == 0 {
+		opts.Size = 3
+	}
+	if opts.Size < minTicTacToeSize || opts.Size > maxTicTacToeSize {
+		return nil, fmt.Errorf("размер поля должен быть от %d до %d", minTicTacToeSize, maxTicTacToeSize)
+	}
+	if opts.WinLength == 0 {
+		opts.WinLength = min(opts.Size, 5)
+	}
+	if opts.WinLength < 3 || opts.WinLength > opts.Size {
+		return nil, fmt.Errorf("длина ряда должна быть от 3 до %d", opts.Size)
+	}
+
+	return &TicTacToeState{
+		Size:      opts.Size,
+		WinLength: opts.WinLength,
+		Board:     newGrid(opts.Size),
+	}, nil
+}
+
+func (TicTacToeEngine) Symbol(playerIndex int) string {
+	if playerIndex == 0 {
+		return "X"
+	}
+	return "O"
+}
+
+func (TicTacToeEngine) StartStatus() string {
+	return "playing"
+}
+
+func (e TicTacToeEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	if action != "move" {
+		return fmt.Errorf("неизвестное действие")
+	}
+
+	var move MoveData
+	if err := json.Unmarshal(data, &move); err != nil {
+		return fmt.Errorf("некорректный ход")
+	}
+	if move.Position != nil {
+		size := game.State.(*TicTacToeState).Size
+		move.Row, move.Col = *move.Position/size, *move.Position%size
+	}
+	return e.makeMove(game, playerIndex, move.Row, move.Col)
+}
+
+// makeMove делает ход в крестики-нолики
+func (TicTacToeEngine) makeMove(game *Game, playerIndex, row, col int) error {
+	state := game.State.(*TicTacToeState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	if row < 0 || row >= state.Size || col < 0 || col >= state.Size {
+		return fmt.Errorf("неверная позиция")
+	}
+
+	if state.Board[row][col] != "" {
+		return fmt.Errorf("позиция уже занята")
+	}
+
+	state.Board[row][col] = game.Players[playerIndex].Symbol
+	state.LastMove = &[2]int{row, col}
+
+	if checkWinnerTicTacToe(state.Board, state.WinLength, row, col) == "" && !isBoardFull(state.Board) {
+		game.Turn = 1 - game.Turn
+	}
+	return nil
+}
+
+func (TicTacToeEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*TicTacToeState)
+	if state.LastMove == nil {
+		return false, ""
+	}
+	if winner := checkWinnerTicTacToe(state.Board, state.WinLength, state.LastMove[0], state.LastMove[1]); winner != "" {
+		return true, winner
+	}
+	if isBoardFull(state.Board) {
+		return true, "draw"
+	}
+	return false, ""
+}
+
+func (TicTacToeEngine) View(game *Game, playerIndex int) interface{} {
+	state := *game.State.(*TicTacToeState)
+	state.Board = copyGrid(state.Board)
+	return &state
+}
+
+func (e TicTacToeEngine) Reset(game *Game) {
+	// Настройки проверены при создании игры
+	game.State, _ = e.NewState(game.Options)
+}
+
+// UnmarshalJSON читает и поле строками, и плоское поле 3x3 из старых сохранений
+func (s *TicTacToeState) UnmarshalJSON(data []byte) error {
+	type plainState TicTacToeState
+	raw := struct {
+		*plainState
+		Board json.RawMessage `json:"board"`
+	}{plainState: (*plainState)(s)}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.Board) == 0 {
+		return nil
+	}
+
+	var flat [9]string
+	if err := json.Unmarshal(raw.Board, &flat); err == nil {
+		s.Size, s.WinLength = 3, 3
+		s.Board = newGrid(3)
+		for i, cell := range flat {
+			s.Board[i/3][i%3] = cell
+		}
+		return nil
+	}
+	return json.Unmarshal(raw.Board, &s.Board)
+}
+
+// ticTacToeDirections направления линий: по горизонтали, вертикали и двум диагоналям
+var ticTacToeDirections = [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+
+// checkWinnerTicTacToe проверяет только линии, проходящие через последний
+// ход (row, col): выиграть мог лишь тот, кто его сделал
+func checkWinnerTicTacToe(board [][]string, winLength, row, col int) string {
+	symbol := board[row][col]
+	if symbol == "" {
+		return ""
+	}
+
+	for _, direction := range ticTacToeDirections {
+		count := 1 + countInRow(board, row, col, direction[0], direction[1], symbol) +
+			countInRow(board, row, col, -direction[0], -direction[1], symbol)
+		if count >= winLength {
+			return symbol
+		}
+	}
+	return ""
+}
+
+// countInRow считает символы symbol подряд от (row, col) в направлении (dr, dc), не считая саму клетку
+func countInRow(board [][]string, row, col, dr, dc int, symbol string) int {
+	count := 0
+	for r, c := row+dr, col+dc; r >= 0 && r < len(board) && c >= 0 && c < len(board[r]) && board[r][c] == symbol; r, c = r+dr, c+dc {
+		count++
+	}
+	return count
+}
+
+func isBoardFull(board [][]string) bool {
+	for _, row := range board {
+		for _, cell := range row {
+			if cell == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var ticTacToePlayers = []Player{{ID: "a", Symbol: "X"}, {ID: "b", Symbol: "O"}}
+
+func TestTicTacToeLegacyPosition(t *testing.T) {
+	tests := []struct {
+		options  string
+		position int
+		row, col int
+	}{
+		{`{"size":3,"winLength":3}`, 4, 1, 1},
+		{`{"size":3,"winLength":3}`, 5, 1, 2},
+		{`{"size":4,"winLength":3}`, 4, 1, 0},
+		{`{"size":10,"winLength":5}`, 57, 5, 7},
+	}
+
+	engine := TicTacToeEngine{}
+	for _, tt := range tests {
+		state, err := engine.NewState(json.RawMessage(tt.options))
+		if err != nil {
+			t.Fatalf("NewState(%s): %v", tt.options, err)
+		}
+		game := &Game{State: state, Players: ticTacToePlayers, Status: "playing"}
+
+		data, _ := json.Marshal(map[string]int{"position": tt.position})
+		if err := engine.Apply(game, 0, "move", data); err != nil {
+			t.Fatalf("%s, позиция %d: %v", tt.options, tt.position, err)
+		}
+		if got := state.(*TicTacToeState).Board[tt.row][tt.col]; got != "X" {
+			t.Errorf("%s, позиция %d: клетка (%d, %d) = %q, ожидался X", tt.options, tt.position, tt.row, tt.col, got)
+		}
+	}
+
+	// Номер за пределами поля отклоняется
+	state, _ := engine.NewState(json.RawMessage(`{"size":4,"winLength":3}`))
+	game := &Game{State: state, Players: ticTacToePlayers, Status: "playing"}
+	if err := engine.Apply(game, 0, "move", json.RawMessage(`{"position":16}`)); err == nil {
+		t.Error("Apply() принял позицию 16 на поле 4x4")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// UltimateState состояние игры в ультимативные крестики-нолики: девять
+// малых полей 3x3 внутри большого. Поля и клетки нумеруются от 0 до 8
+// построчно
+type UltimateState struct {
+	Boards   [][][]string  `json:"boards"`             // Boards[board][row][col]
+	Winners  []string      `json:"winners"`            // Итог малого поля: "", "X", "O" или "draw"
+	Forced   int           `json:"forced"`             // Поле, на котором обязан ходить игрок, -1 — любое
+	LastMove *UltimateMove `json:"lastMove,omitempty"` // Последний ход
+}
+
+// UltimateMove ход в клетку Cell малого поля Board
+type UltimateMove struct {
+	Board int `json:"board"`
+	Cell  int `json:"cell"`
+}
+
+// UltimateMoveData для передачи хода в ультимативные крестики-нолики
+type UltimateMoveData struct {
+	GameID   string `json:"gameId"`
+	Board    int    `json:"board"`
+	Cell     int    `json:"cell"`
+	PlayerID string `json:"playerId"`
+}
+
+// UltimateEngine правила ультимативных крестиков-ноликов
+type UltimateEngine struct{}
+
+func init() {
+	registerEngine("ultimate", UltimateEngine{})
+}
+
+func (UltimateEngine) NewState(options json.RawMessage) (interface{}, error) {
+	state := &UltimateState{
+		Boards:  make([][][]string, 9),
+		Winners: make([]string, 9),
+		Forced:  -1,
+	}
+	for i := range state.Boards {
+		state.Boards[i] = newGrid(3)
+	}
+	return state, nil
+}
+
+func (UltimateEngine) Symbol(playerIndex int) string {
+	return TicTacToeEngine{}.Symbol(playerIndex)
+}
+
+func (UltimateEngine) StartStatus() string {
+	return "playing"
+}
+
+func (e UltimateEngine) Apply(game *Game, playerIndex int, action string, data json.RawMessage) error {
+	if action != "move" {
+		return fmt.Errorf("неизвестное действие")
+	}
+
+	var move UltimateMoveData
+	if err := json.Unmarshal(data, &move); err != nil {
+		return fmt.Errorf("некорректный ход")
+	}
+	return e.makeMove(game, playerIndex, move.Board, move.Cell)
+}
+
+// makeMove делает ход в клетку cell малого поля board
+func (e UltimateEngine) makeMove(game *Game, playerIndex, board, cell int) error {
+	state := game.State.(*UltimateState)
+
+	if err := checkTurn(game, playerIndex); err != nil {
+		return err
+	}
+
+	if board < 0 || board > 8 || cell < 0 || cell > 8 {
+		return fmt.Errorf("неверная позиция")
+	}
+
+	if state.Forced != -1 && board != state.Forced {
+		return fmt.Errorf("ходить нужно на поле %d", state.Forced+1)
+	}
+
+	if state.Winners[board] != "" {
+		return fmt.Errorf("это поле уже разыграно")
+	}
+
+	sub := state.Boards[board]
+	row, col := cell/3, cell%3
+	if sub[row][col] != "" {
+		return fmt.Errorf("позиция уже занята")
+	}
+
+	sub[row][col] = game.Players[playerIndex].Symbol
+	state.LastMove = &UltimateMove{Board: board, Cell: cell}
+
+	if winner := checkWinnerTicTacToe(sub, 3, row, col); winner != "" {
+		state.Winners[board] = winner
+	} else if isBoardFull(sub) {
+		state.Winners[board] = "draw"
+	}
+
+	// Соперник ходит на поле, соответствующее клетке хода; если оно
+	// уже разыграно — на любое
+	state.Forced = cell
+	if state.Winners[cell] != "" {
+		state.Forced = -1
+	}
+
+	if finished, _ := e.Outcome(game); !finished {
+		game.Turn = 1 - game.Turn
+	}
+	return nil
+}
+
+func (UltimateEngine) Outcome(game *Game) (bool, string) {
+	state := game.State.(*UltimateState)
+	if state.LastMove == nil {
+		return false, ""
+	}
+
+	// Большое поле: малые поля с ничьей не принадлежат никому
+	macro := newGrid(3)
+	for i, winner := range state.Winners {
+		if winner != "draw" {
+			macro[i/3][i%3] = winner
+		}
+	}
+
+	board := state.LastMove.Board
+	if winner := checkWinnerTicTacToe(macro, 3, board/3, board%3); winner != "" {
+		return true, winner
+	}
+
+	for _, winner := range state.Winners {
+		if winner == "" {
+			return false, ""
+		}
+	}
+	return true, "draw"
+}
+
+func (UltimateEngine) View(game *Game, playerIndex int) interface{} {
+	state := *game.State.(*UltimateState)
+	state.Boards = make([][][]string, len(state.Boards))
+	for i, board := range game.State.(*UltimateState).Boards {
+		state.Boards[i] = copyGrid(board)
+	}
+	state.Winners = append([]string{}, state.Winners...)
+	return &state
+}
+
+func (e UltimateEngine) Reset(game *Game) {
+	game.State, _ = e.NewState(game.Options)
+}
@@ -0,0 +1,15 @@
+package main
+
+// gameView возвращает представление игры для конкретного игрока.
+// Через него проходит всё состояние, которое отправляется клиентам.
+func gameView(game *Game, playerID string) *Game {
+	view := *game
+	view.Players = append([]Player{}, game.Players...)
+	view.RestartVotes = append([]string{}, game.RestartVotes...)
+	view.Series = game.Series.copy()
+	view.Events = nil // Журнал может раскрыть скрытое (например, расстановку кораблей)
+
+	engine, _ := engineFor(game.Type)
+	view.State = engine.View(game, game.playerIndex(playerID))
+	return &view
+}